@@ -3,8 +3,10 @@ package commands
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/go-errors/errors"
 	"github.com/jesseduffield/lazygit/pkg/commands/oscommands"
 	"github.com/jesseduffield/lazygit/pkg/utils"
 )
@@ -116,6 +118,82 @@ func (c *GitCommand) GetCommitDifferences(from, to string) (string, string) {
 	return strings.TrimSpace(pushableCount), strings.TrimSpace(pullableCount)
 }
 
+// CommitsAhead returns how many commits head has that base doesn't, and how
+// many base has that head doesn't, in that order
+func (c *GitCommand) CommitsAhead(base string, head string) (int, int, error) {
+	output, err := c.OSCommand.RunCommandWithOutput("git rev-list --left-right --count %s...%s", base, head)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// the left count is of commits only in base, the right one only in head
+	counts := strings.Fields(output)
+	if len(counts) != 2 {
+		return 0, 0, errors.New("unexpected output from git rev-list: " + output)
+	}
+
+	behind, err := strconv.Atoi(counts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ahead, err := strconv.Atoi(counts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return ahead, behind, nil
+}
+
+// LastPushedBranch returns the name of the branch we pushed most recently,
+// going by the reflogs of the remote-tracking branches, for suggesting which
+// branch to open a pull request for. It returns an empty string if we've never
+// pushed (or the reflogs have since expired)
+func (c *GitCommand) LastPushedBranch() (string, error) {
+	output, err := c.OSCommand.RunCommandWithOutput("git reflog show --all --date=unix --format=%s", "%gD%x09%gs")
+	if err != nil {
+		return "", err
+	}
+
+	return parseLastPushedBranch(output), nil
+}
+
+// parseLastPushedBranch picks the newest push out of reflog entries like
+// 'refs/remotes/origin/feature/x@{1609459200}\tupdate by push'
+func parseLastPushedBranch(output string) string {
+	lastPushedBranch := ""
+	var lastPushedAt int64
+	for _, line := range utils.SplitLines(output) {
+		fields := strings.SplitN(line, "\t", 2)
+		if len(fields) != 2 || fields[1] != "update by push" {
+			continue
+		}
+
+		selectorIndex := strings.LastIndex(fields[0], "@{")
+		if selectorIndex == -1 || !strings.HasSuffix(fields[0], "}") {
+			continue
+		}
+		pushedAt, err := strconv.ParseInt(fields[0][selectorIndex+2:len(fields[0])-1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		// the ref is refs/remotes/<remote>/<branch>
+		ref := strings.TrimPrefix(fields[0][:selectorIndex], "refs/remotes/")
+		slashIndex := strings.Index(ref, "/")
+		if slashIndex == -1 {
+			continue
+		}
+
+		if lastPushedBranch == "" || pushedAt > lastPushedAt {
+			lastPushedBranch = ref[slashIndex+1:]
+			lastPushedAt = pushedAt
+		}
+	}
+
+	return lastPushedBranch
+}
+
 type MergeOpts struct {
 	FastForwardOnly bool
 }
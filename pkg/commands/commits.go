@@ -3,11 +3,13 @@ package commands
 import (
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/jesseduffield/lazygit/pkg/commands/models"
 	"github.com/jesseduffield/lazygit/pkg/commands/oscommands"
+	"github.com/jesseduffield/lazygit/pkg/utils"
 )
 
 // RenameCommit renames the topmost commit with the given name
@@ -97,3 +99,21 @@ func (c *GitCommand) CherryPickCommits(commits []*models.Commit) error {
 func (c *GitCommand) CreateFixupCommit(sha string) error {
 	return c.OSCommand.RunCommand("git commit --fixup=%s", sha)
 }
+
+// PreparePatches writes a patch file for each commit in base..head, ready to be
+// sent with git send-email, and returns the paths of those files. The patches
+// go in .git/lazygit-patches so that they don't clutter the worktree
+func (c *GitCommand) PreparePatches(base string, head string) ([]string, error) {
+	outputDir := filepath.Join(c.DotGitDir, "lazygit-patches")
+	output, err := c.OSCommand.RunCommandWithOutput(
+		"git format-patch --output-directory %s %s..%s",
+		c.OSCommand.Quote(outputDir),
+		base,
+		head,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.SplitLines(output), nil
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -53,3 +54,20 @@ func (c *GitCommand) GetConfigValue(key string) string {
 	value, _ = c.getGlobalGitConfig(key)
 	return value
 }
+
+// HasUpstream returns whether the given branch has a remote configured to push
+// to and pull from, which it won't have if it's never been pushed
+func (c *GitCommand) HasUpstream(branchName string) (bool, error) {
+	remote, err := c.getLocalGitConfig(fmt.Sprintf("branch.%s.remote", branchName))
+	if err != nil && !isGitConfigKeyNotFound(err) {
+		return false, err
+	}
+
+	return remote != "", nil
+}
+
+// isGitConfigKeyNotFound returns true for the error gitconfig gives us when the
+// key isn't set, as opposed to when git config itself fails
+func isGitConfigKeyNotFound(err error) bool {
+	return strings.HasSuffix(err.Error(), "is not found")
+}
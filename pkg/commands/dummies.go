@@ -1,8 +1,10 @@
 package commands
 
 import (
+	"io/ioutil"
+	"os"
+
 	"github.com/jesseduffield/lazygit/pkg/commands/oscommands"
-	"github.com/jesseduffield/lazygit/pkg/config"
 	"github.com/jesseduffield/lazygit/pkg/i18n"
 	"github.com/jesseduffield/lazygit/pkg/utils"
 )
@@ -18,9 +20,31 @@ func NewDummyGitCommandWithOSCommand(osCommand *oscommands.OSCommand) *GitComman
 		Log:                utils.NewDummyLog(),
 		OSCommand:          osCommand,
 		Tr:                 i18n.NewTranslationSet(utils.NewDummyLog()),
-		Config:             config.NewDummyAppConfig(),
+		Config:             osCommand.Config,
 		getGlobalGitConfig: func(string) (string, error) { return "", nil },
 		getLocalGitConfig:  func(string) (string, error) { return "", nil },
+		getGitConfigRegexp: func(string) (string, error) { return "", nil },
 		removeFile:         func(string) error { return nil },
+		readFile:           ioutil.ReadFile,
+		writeFile:          ioutil.WriteFile,
+	}
+}
+
+// NewDummyGitCommandWithFiles creates a new dummy GitCommand for testing whose
+// file reads and writes go to the given map of paths to contents instead of disk
+func NewDummyGitCommandWithFiles(files map[string]string) *GitCommand {
+	gitCommand := NewDummyGitCommand()
+	gitCommand.DotGitDir = ".git"
+	gitCommand.readFile = func(path string) ([]byte, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+		}
+		return []byte(content), nil
+	}
+	gitCommand.writeFile = func(path string, data []byte, perm os.FileMode) error {
+		files[path] = string(data)
+		return nil
 	}
+	return gitCommand
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/go-errors/errors"
 
@@ -34,13 +35,26 @@ type GitCommand struct {
 	Config               config.AppConfigurer
 	getGlobalGitConfig   func(string) (string, error)
 	getLocalGitConfig    func(string) (string, error)
+	getGitConfigRegexp   func(string) (string, error)
 	removeFile           func(string) error
+	readFile             func(string) ([]byte, error)
+	writeFile            func(string, []byte, os.FileMode) error
 	DotGitDir            string
 	onSuccessfulContinue func() error
 	PatchManager         *patch.PatchManager
 
 	// Push to current determines whether the user has configured to push to the remote branch of the same name as the current or not
 	PushToCurrent bool
+
+	// remoteRepoInfoCache memoises the url and parsed repo information of each
+	// remote, keyed by remote name. It's cleared whenever we change a remote
+	remoteRepoInfoCache map[string]*remoteRepoInfo
+	remoteRepoInfoMutex sync.Mutex
+
+	// defaultBranch memoises the result of GetDefaultBranch. It's cleared
+	// alongside remoteRepoInfoCache
+	defaultBranch       string
+	defaultBranchLoaded bool
 }
 
 // NewGitCommand it runs git commands
@@ -82,11 +96,16 @@ func NewGitCommand(log *logrus.Entry, osCommand *oscommands.OSCommand, tr *i18n.
 		getGlobalGitConfig: gitconfig.Global,
 		getLocalGitConfig:  gitconfig.Local,
 		removeFile:         os.RemoveAll,
+		readFile:           ioutil.ReadFile,
+		writeFile:          ioutil.WriteFile,
 		DotGitDir:          dotGitDir,
 		PushToCurrent:      pushToCurrent,
 	}
 
 	gitCommand.PatchManager = patch.NewPatchManager(log, gitCommand.ApplyPatch, gitCommand.ShowFileDiff)
+	gitCommand.getGitConfigRegexp = func(pattern string) (string, error) {
+		return osCommand.RunCommandWithOutput("git config --get-regexp %s", pattern)
+	}
 
 	return gitCommand, nil
 }
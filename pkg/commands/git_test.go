@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"regexp"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -594,6 +595,70 @@ func TestGitCommandGetCommitDifferences(t *testing.T) {
 	}
 }
 
+// TestGitCommandCommitsAhead is a function.
+func TestGitCommandCommitsAhead(t *testing.T) {
+	type scenario struct {
+		testName string
+		command  func(string, ...string) *exec.Cmd
+		test     func(int, int, error)
+	}
+
+	scenarios := []scenario{
+		{
+			"returns the commits ahead and behind",
+			func(cmd string, args ...string) *exec.Cmd {
+				assert.EqualValues(t, "git", cmd)
+				assert.EqualValues(t, []string{"rev-list", "--left-right", "--count", "master...feature"}, args)
+				return exec.Command("printf", "2\t5\n")
+			},
+			func(ahead int, behind int, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, 5, ahead)
+				assert.EqualValues(t, 2, behind)
+			},
+		},
+		{
+			"returns zero ahead for a branch with nothing to merge",
+			func(cmd string, args ...string) *exec.Cmd {
+				return exec.Command("printf", "3\t0\n")
+			},
+			func(ahead int, behind int, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, 0, ahead)
+				assert.EqualValues(t, 3, behind)
+			},
+		},
+		{
+			"errors on unexpected output",
+			func(cmd string, args ...string) *exec.Cmd {
+				return exec.Command("echo", "5")
+			},
+			func(ahead int, behind int, err error) {
+				assert.Error(t, err)
+			},
+		},
+		{
+			"bubbles up error if there is one",
+			func(cmd string, args ...string) *exec.Cmd {
+				return exec.Command("test")
+			},
+			func(ahead int, behind int, err error) {
+				assert.Error(t, err)
+				assert.EqualValues(t, 0, ahead)
+				assert.EqualValues(t, 0, behind)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			gitCmd := NewDummyGitCommand()
+			gitCmd.OSCommand.Command = s.command
+			s.test(gitCmd.CommitsAhead("master", "feature"))
+		})
+	}
+}
+
 // TestGitCommandRenameCommit is a function.
 func TestGitCommandRenameCommit(t *testing.T) {
 	gitCmd := NewDummyGitCommand()
@@ -2047,6 +2112,66 @@ func TestGitCommandCreateFixupCommit(t *testing.T) {
 	}
 }
 
+// TestGitCommandPreparePatches is a function.
+func TestGitCommandPreparePatches(t *testing.T) {
+	type scenario struct {
+		testName string
+		command  func(string, ...string) *exec.Cmd
+		test     func([]string, error)
+	}
+
+	scenarios := []scenario{
+		{
+			"returns the paths of the generated patches",
+			func(cmd string, args ...string) *exec.Cmd {
+				assert.EqualValues(t, "git", cmd)
+				assert.EqualValues(t, []string{"format-patch", "--output-directory", ".git/lazygit-patches", "master..feature"}, args)
+
+				return exec.Command("printf", "%s\n%s\n",
+					".git/lazygit-patches/0001-Add-sum.patch",
+					".git/lazygit-patches/0002-Add-tests.patch",
+				)
+			},
+			func(paths []string, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, []string{
+					".git/lazygit-patches/0001-Add-sum.patch",
+					".git/lazygit-patches/0002-Add-tests.patch",
+				}, paths)
+			},
+		},
+		{
+			"returns no paths when there are no commits in the range",
+			func(cmd string, args ...string) *exec.Cmd {
+				return exec.Command("echo", "-n")
+			},
+			func(paths []string, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, paths, 0)
+			},
+		},
+		{
+			"bubbles up error if there is one",
+			func(cmd string, args ...string) *exec.Cmd {
+				return exec.Command("test")
+			},
+			func(paths []string, err error) {
+				assert.Error(t, err)
+				assert.Nil(t, paths)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			gitCmd := NewDummyGitCommand()
+			gitCmd.DotGitDir = ".git"
+			gitCmd.OSCommand.Command = s.command
+			s.test(gitCmd.PreparePatches("master", "feature"))
+		})
+	}
+}
+
 // TestGitCommandSkipEditorCommand confirms that SkipEditorCommand injects
 // environment variables that suppress an interactive editor
 func TestGitCommandSkipEditorCommand(t *testing.T) {
@@ -2310,3 +2435,624 @@ func TestEditFile(t *testing.T) {
 		s.test(gitCmd.EditFile(s.filename))
 	}
 }
+
+// TestGitCommandGetRemoteURL is a function.
+func TestGitCommandGetRemoteURL(t *testing.T) {
+	type scenario struct {
+		testName     string
+		localConfig  string
+		globalConfig string
+		test         func(string, error)
+	}
+
+	scenarios := []scenario{
+		{
+			testName:     "local config takes precedence",
+			localConfig:  "git@github.com:peter/calculator.git",
+			globalConfig: "git@github.com:mathcorp/calculator.git",
+			test: func(url string, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "git@github.com:peter/calculator.git", url)
+			},
+		},
+		{
+			testName:     "falls back to global config",
+			globalConfig: "git@github.com:mathcorp/calculator.git",
+			test: func(url string, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "git@github.com:mathcorp/calculator.git", url)
+			},
+		},
+		{
+			testName: "errors when the remote has no url",
+			test: func(url string, err error) {
+				assert.EqualError(t, err, "Could not find a url for remote 'upstream'")
+				assert.EqualValues(t, "", url)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			gitCmd := NewDummyGitCommand()
+			gitCmd.getLocalGitConfig = func(path string) (string, error) {
+				assert.EqualValues(t, "remote.upstream.url", path)
+				return s.localConfig, nil
+			}
+			gitCmd.getGlobalGitConfig = func(path string) (string, error) {
+				assert.EqualValues(t, "remote.upstream.url", path)
+				return s.globalConfig, nil
+			}
+
+			s.test(gitCmd.GetRemoteURL("upstream"))
+		})
+	}
+}
+
+// TestGitCommandGetRemoteRepoInfo is a function.
+func TestGitCommandGetRemoteRepoInfo(t *testing.T) {
+	gitCmd := NewDummyGitCommand()
+	gitCmd.OSCommand.Command = func(cmd string, args ...string) *exec.Cmd {
+		return exec.Command("echo")
+	}
+
+	remoteURL := "git@github.com:peter/calculator.git"
+	lookups := 0
+	gitCmd.getLocalGitConfig = func(path string) (string, error) {
+		assert.EqualValues(t, "remote.origin.url", path)
+		lookups++
+		return remoteURL, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		url, repoInfo, err := gitCmd.getRemoteRepoInfo("origin")
+		assert.NoError(t, err)
+		assert.EqualValues(t, remoteURL, url)
+		assert.EqualValues(t, "peter", repoInfo.Owner)
+		assert.EqualValues(t, "calculator", repoInfo.Repository)
+	}
+	assert.EqualValues(t, 1, lookups)
+
+	remoteURL = "git@github.com:mathcorp/calculator.git"
+	assert.NoError(t, gitCmd.UpdateRemoteUrl("origin", remoteURL))
+
+	_, repoInfo, _ := gitCmd.getRemoteRepoInfo("origin")
+	assert.EqualValues(t, "mathcorp", repoInfo.Owner)
+	assert.EqualValues(t, 2, lookups)
+}
+
+// TestGitCommandGetDefaultBranch is a function.
+func TestGitCommandGetDefaultBranch(t *testing.T) {
+	type scenario struct {
+		testName      string
+		existingRefs  []string
+		originHEAD    string
+		expected      string
+		expectedCalls int
+	}
+
+	scenarios := []scenario{
+		{
+			testName:      "reads origin's HEAD",
+			originHEAD:    "refs/remotes/origin/develop",
+			existingRefs:  []string{"refs/remotes/origin/master"},
+			expected:      "develop",
+			expectedCalls: 1,
+		},
+		{
+			testName:      "falls back to main on origin",
+			existingRefs:  []string{"refs/heads/master", "refs/remotes/origin/main"},
+			expected:      "main",
+			expectedCalls: 2,
+		},
+		{
+			testName:      "falls back to master on origin",
+			existingRefs:  []string{"refs/heads/main", "refs/remotes/origin/master"},
+			expected:      "master",
+			expectedCalls: 3,
+		},
+		{
+			testName:      "falls back to a local branch when there is no remote",
+			existingRefs:  []string{"refs/heads/master"},
+			expected:      "master",
+			expectedCalls: 5,
+		},
+		{
+			testName:      "returns an empty string when nothing matches",
+			existingRefs:  []string{"refs/heads/trunk"},
+			expected:      "",
+			expectedCalls: 5,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			gitCmd := NewDummyGitCommand()
+			calls := 0
+			gitCmd.OSCommand.Command = func(cmd string, args ...string) *exec.Cmd {
+				assert.EqualValues(t, "git", cmd)
+				calls++
+
+				switch args[0] {
+				case "symbolic-ref":
+					assert.EqualValues(t, []string{"symbolic-ref", "refs/remotes/origin/HEAD"}, args)
+					if s.originHEAD == "" {
+						return exec.Command("test")
+					}
+					return exec.Command("echo", s.originHEAD)
+				case "show-ref":
+					for _, ref := range s.existingRefs {
+						if args[len(args)-1] == ref {
+							return exec.Command("echo")
+						}
+					}
+					return exec.Command("test")
+				}
+
+				return nil
+			}
+
+			// the result is cached, so asking twice only shells out once
+			assert.EqualValues(t, s.expected, gitCmd.GetDefaultBranch())
+			assert.EqualValues(t, s.expected, gitCmd.GetDefaultBranch())
+			assert.EqualValues(t, s.expectedCalls, calls)
+		})
+	}
+}
+
+// TestGitCommandEditRebaseTodo is a function.
+func TestGitCommandEditRebaseTodo(t *testing.T) {
+	type scenario struct {
+		testName string
+		files    map[string]string
+		test     func(map[string]string, error)
+	}
+
+	scenarios := []scenario{
+		{
+			"rewrites the action of the given commit",
+			map[string]string{
+				".git/rebase-merge/git-rebase-todo": "pick 1234567 first commit\npick 89abcde second commit\n\n# Rebase 0123456..89abcde onto 0123456",
+			},
+			func(files map[string]string, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "pick 1234567 first commit\nsquash 89abcde second commit\n\n# Rebase 0123456..89abcde onto 0123456", files[".git/rebase-merge/git-rebase-todo"])
+			},
+		},
+		{
+			"fails when there is no rebase in progress",
+			map[string]string{},
+			func(files map[string]string, err error) {
+				assert.Error(t, err)
+				assert.True(t, os.IsNotExist(err))
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			gitCmd := NewDummyGitCommandWithFiles(s.files)
+			s.test(s.files, gitCmd.EditRebaseTodo(0, "squash"))
+		})
+	}
+}
+
+// TestGitCommandMoveTodoDown is a function.
+func TestGitCommandMoveTodoDown(t *testing.T) {
+	files := map[string]string{
+		".git/rebase-merge/git-rebase-todo": "pick 1234567 first commit\npick 89abcde second commit\npick fedcba9 third commit\n",
+	}
+	gitCmd := NewDummyGitCommandWithFiles(files)
+
+	assert.NoError(t, gitCmd.MoveTodoDown(1))
+	assert.EqualValues(t, "pick 89abcde second commit\npick 1234567 first commit\npick fedcba9 third commit\n", files[".git/rebase-merge/git-rebase-todo"])
+}
+
+// TestGitCommandLastPushedBranch is a function.
+func TestGitCommandLastPushedBranch(t *testing.T) {
+	type scenario struct {
+		testName string
+		command  func(string, ...string) *exec.Cmd
+		test     func(string, error)
+	}
+
+	scenarios := []scenario{
+		{
+			"returns the most recently pushed branch",
+			func(cmd string, args ...string) *exec.Cmd {
+				assert.EqualValues(t, "git", cmd)
+				assert.EqualValues(t, []string{"reflog", "show", "--all", "--date=unix", "--format=%gD%x09%gs"}, args)
+				return exec.Command("printf", strings.Join([]string{
+					"refs/heads/feature/x@{1609459300}\tcommit: add sum",
+					"refs/remotes/origin/feature/x@{1609459200}\tupdate by push",
+					"refs/remotes/origin/master@{1609459500}\tfetch: fast-forward",
+					"refs/remotes/origin/master@{1609459100}\tupdate by push",
+					"refs/remotes/origin/master@{1609459000}\tupdate by push",
+					"HEAD@{1609459300}\tcommit: add sum",
+				}, "\n"))
+			},
+			func(branchName string, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "feature/x", branchName)
+			},
+		},
+		{
+			"returns the branch pushed last out of several pushes to different remotes",
+			func(cmd string, args ...string) *exec.Cmd {
+				return exec.Command("printf", strings.Join([]string{
+					"refs/remotes/origin/feature/x@{1609459200}\tupdate by push",
+					"refs/remotes/upstream/feature/y@{1609459900}\tupdate by push",
+				}, "\n"))
+			},
+			func(branchName string, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "feature/y", branchName)
+			},
+		},
+		{
+			"returns an empty string if nothing has been pushed",
+			func(cmd string, args ...string) *exec.Cmd {
+				return exec.Command("printf", strings.Join([]string{
+					"refs/heads/master@{1609459300}\tcommit (initial): first commit",
+					"refs/remotes/origin/master@{1609459500}\tfetch: fast-forward",
+				}, "\n"))
+			},
+			func(branchName string, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "", branchName)
+			},
+		},
+		{
+			"bubbles up error if there is one",
+			func(cmd string, args ...string) *exec.Cmd {
+				return exec.Command("test")
+			},
+			func(branchName string, err error) {
+				assert.Error(t, err)
+				assert.EqualValues(t, "", branchName)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			gitCmd := NewDummyGitCommand()
+			gitCmd.OSCommand.Command = s.command
+			s.test(gitCmd.LastPushedBranch())
+		})
+	}
+}
+
+// TestGitCommandHasUpstream is a function.
+func TestGitCommandHasUpstream(t *testing.T) {
+	type scenario struct {
+		testName    string
+		localConfig func(string) (string, error)
+		test        func(bool, error)
+	}
+
+	scenarios := []scenario{
+		{
+			testName: "has an upstream when a remote is configured",
+			localConfig: func(path string) (string, error) {
+				assert.EqualValues(t, "branch.feature/x.remote", path)
+				return "origin", nil
+			},
+			test: func(hasUpstream bool, err error) {
+				assert.NoError(t, err)
+				assert.True(t, hasUpstream)
+			},
+		},
+		{
+			testName: "has no upstream when no remote is configured",
+			localConfig: func(path string) (string, error) {
+				return "", fmt.Errorf("the key `%s` is not found", path)
+			},
+			test: func(hasUpstream bool, err error) {
+				assert.NoError(t, err)
+				assert.False(t, hasUpstream)
+			},
+		},
+		{
+			testName: "bubbles up error if git config fails",
+			localConfig: func(path string) (string, error) {
+				return "", errors.New("error")
+			},
+			test: func(hasUpstream bool, err error) {
+				assert.EqualError(t, err, "error")
+				assert.False(t, hasUpstream)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			gitCmd := NewDummyGitCommand()
+			gitCmd.getLocalGitConfig = s.localConfig
+
+			s.test(gitCmd.HasUpstream("feature/x"))
+		})
+	}
+}
+
+// TestGitCommandGetRepoInformation is a function.
+func TestGitCommandGetRepoInformation(t *testing.T) {
+	type scenario struct {
+		testName     string
+		localConfig  func(string) (string, error)
+		globalConfig func(string) (string, error)
+		test         func(*RepoInformation, error)
+	}
+
+	noConfig := func(string) (string, error) {
+		return "", errors.New("the key is not found")
+	}
+
+	scenarios := []scenario{
+		{
+			testName: "parses the url of a remote in the repo's config",
+			localConfig: func(path string) (string, error) {
+				assert.EqualValues(t, "remote.origin.url", path)
+				return "git@github.com:peter/calculator.git", nil
+			},
+			globalConfig: noConfig,
+			test: func(repoInfo *RepoInformation, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "peter", repoInfo.Owner)
+				assert.EqualValues(t, "calculator", repoInfo.Repository)
+			},
+		},
+		{
+			testName:    "parses the url of a remote in the global config",
+			localConfig: noConfig,
+			globalConfig: func(path string) (string, error) {
+				return "https://gitlab.com/peter/calculator", nil
+			},
+			test: func(repoInfo *RepoInformation, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "peter", repoInfo.Owner)
+				assert.EqualValues(t, "calculator", repoInfo.Repository)
+			},
+		},
+		{
+			testName:     "returns an error if the remote has no url",
+			localConfig:  noConfig,
+			globalConfig: noConfig,
+			test: func(repoInfo *RepoInformation, err error) {
+				assert.EqualError(t, err, "Could not find a url for remote 'origin'")
+				assert.Nil(t, repoInfo)
+			},
+		},
+		{
+			testName: "returns an error if the url can't be parsed",
+			localConfig: func(string) (string, error) {
+				return "https://github.com/calculator", nil
+			},
+			globalConfig: noConfig,
+			test: func(repoInfo *RepoInformation, err error) {
+				assert.Error(t, err)
+				assert.Nil(t, repoInfo)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			gitCmd := NewDummyGitCommand()
+			gitCmd.getLocalGitConfig = s.localConfig
+			gitCmd.getGlobalGitConfig = s.globalConfig
+
+			s.test(gitCmd.GetRepoInformation("origin"))
+		})
+	}
+}
+
+// TestGitCommandGetRemoteURLFromGit is a function.
+func TestGitCommandGetRemoteURLFromGit(t *testing.T) {
+	type scenario struct {
+		testName        string
+		remoteURLSource string
+		runner          *oscommands.FakeCommandRunner
+		expectedCalls   []string
+		test            func(string, error)
+	}
+
+	getURL := "git remote get-url --push origin"
+
+	scenarios := []scenario{
+		{
+			testName:        "Reads the push url from git",
+			remoteURLSource: "git",
+			runner:          oscommands.NewFakeCommandRunner().Expect(getURL, "git@github.com:peter/calculator-push.git\n", nil),
+			expectedCalls:   []string{getURL},
+			test: func(url string, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "git@github.com:peter/calculator-push.git", url)
+			},
+		},
+		{
+			testName:        "Takes the first of several push urls",
+			remoteURLSource: "git",
+			runner: oscommands.NewFakeCommandRunner().Expect(getURL,
+				"git@github.com:peter/calculator.git\ngit@gitlab.com:peter/calculator.git\n", nil),
+			expectedCalls: []string{getURL},
+			test: func(url string, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "git@github.com:peter/calculator.git", url)
+			},
+		},
+		{
+			testName:        "Falls back to the config if git doesn't know the remote",
+			remoteURLSource: "git",
+			runner:          oscommands.NewFakeCommandRunner().Expect(getURL, "error: No such remote 'origin'", errors.New("exit status 2")),
+			expectedCalls:   []string{getURL},
+			test: func(url string, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "git@github.com:peter/calculator.git", url)
+			},
+		},
+		{
+			testName:        "Doesn't ask git when reading from the config",
+			remoteURLSource: "config",
+			runner:          oscommands.NewFakeCommandRunner(),
+			expectedCalls:   []string{},
+			test: func(url string, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "git@github.com:peter/calculator.git", url)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			gitCmd := NewDummyGitCommand()
+			gitCmd.Config.GetUserConfig().PR.RemoteURLSource = s.remoteURLSource
+			gitCmd.OSCommand.Runner = s.runner
+			gitCmd.getLocalGitConfig = func(path string) (string, error) {
+				assert.EqualValues(t, "remote.origin.url", path)
+				return "git@github.com:peter/calculator.git", nil
+			}
+
+			s.test(gitCmd.GetRemoteURL("origin"))
+			assert.EqualValues(t, s.expectedCalls, s.runner.Calls())
+		})
+	}
+}
+
+// TestGitCommandGetRepoInformationURLRewrites is a function.
+func TestGitCommandGetRepoInformationURLRewrites(t *testing.T) {
+	type scenario struct {
+		testName      string
+		remoteURL     string
+		rules         string
+		expectedURL   string
+		expectedOwner string
+	}
+
+	rules := "url.https://git.internal.corp/mirror/.insteadof git@github.com:\n" +
+		"url.https://git.internal.corp/mirror/maths/.insteadof git@gitlab.com:mathcorp/\n" +
+		"url.ssh://git.internal.corp/.pushinsteadof git@github.com:\n"
+
+	scenarios := []scenario{
+		{
+			testName:      "Maps a rewritten url back to the public host",
+			remoteURL:     "https://git.internal.corp/mirror/peter/calculator.git",
+			rules:         rules,
+			expectedURL:   "git@github.com:peter/calculator.git",
+			expectedOwner: "peter",
+		},
+		{
+			testName:      "Maps a rewritten url back by the longest matching base",
+			remoteURL:     "https://git.internal.corp/mirror/maths/calculator.git",
+			rules:         rules,
+			expectedURL:   "git@gitlab.com:mathcorp/calculator.git",
+			expectedOwner: "mathcorp",
+		},
+		{
+			testName:      "Leaves a url which no rule rewrites to",
+			remoteURL:     "git@github.com:peter/calculator.git",
+			rules:         rules,
+			expectedURL:   "git@github.com:peter/calculator.git",
+			expectedOwner: "peter",
+		},
+		{
+			testName:      "Ignores pushInsteadOf rules",
+			remoteURL:     "ssh://git.internal.corp/peter/calculator.git",
+			rules:         rules,
+			expectedURL:   "ssh://git.internal.corp/peter/calculator.git",
+			expectedOwner: "peter",
+		},
+		{
+			testName:      "Leaves the url when there are no rules",
+			remoteURL:     "https://git.internal.corp/mirror/peter/calculator.git",
+			rules:         "",
+			expectedURL:   "https://git.internal.corp/mirror/peter/calculator.git",
+			expectedOwner: "mirror/peter",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			gitCmd := NewDummyGitCommand()
+			gitCmd.getLocalGitConfig = func(path string) (string, error) {
+				return s.remoteURL, nil
+			}
+			gitCmd.getGitConfigRegexp = func(pattern string) (string, error) {
+				assert.EqualValues(t, "^url[.].*[.]insteadof$", pattern)
+				if s.rules == "" {
+					return "", errors.New("exit status 1")
+				}
+				return s.rules, nil
+			}
+
+			url, repoInfo, err := gitCmd.getRemoteRepoInfo("origin")
+			assert.NoError(t, err)
+			assert.EqualValues(t, s.expectedURL, url)
+			assert.EqualValues(t, s.expectedOwner, repoInfo.Owner)
+			assert.EqualValues(t, "calculator", repoInfo.Repository)
+		})
+	}
+}
+
+// TestGitCommandIsWorkingTreeClean is a function.
+func TestGitCommandIsWorkingTreeClean(t *testing.T) {
+	type scenario struct {
+		testName string
+		command  func(string, ...string) *exec.Cmd
+		test     func(bool, error)
+	}
+
+	scenarios := []scenario{
+		{
+			"Clean when git status prints nothing",
+			func(cmd string, args ...string) *exec.Cmd {
+				assert.EqualValues(t, "git", cmd)
+				assert.EqualValues(t, []string{"status", "--porcelain"}, args)
+				return exec.Command("echo")
+			},
+			func(clean bool, err error) {
+				assert.NoError(t, err)
+				assert.True(t, clean)
+			},
+		},
+		{
+			"Dirty with modified and staged files",
+			func(cmd string, args ...string) *exec.Cmd {
+				return exec.Command("echo", " M file1.txt\nA  file2.txt")
+			},
+			func(clean bool, err error) {
+				assert.NoError(t, err)
+				assert.False(t, clean)
+			},
+		},
+		{
+			"Dirty with only untracked files",
+			func(cmd string, args ...string) *exec.Cmd {
+				return exec.Command("echo", "?? file3.txt")
+			},
+			func(clean bool, err error) {
+				assert.NoError(t, err)
+				assert.False(t, clean)
+			},
+		},
+		{
+			"Bubbles up error if git status fails",
+			func(cmd string, args ...string) *exec.Cmd {
+				return exec.Command("test")
+			},
+			func(clean bool, err error) {
+				assert.Error(t, err)
+				assert.False(t, clean)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			gitCmd := NewDummyGitCommand()
+			gitCmd.OSCommand.Command = s.command
+
+			s.test(gitCmd.IsWorkingTreeClean())
+		})
+	}
+}
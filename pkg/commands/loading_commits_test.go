@@ -4,6 +4,7 @@ import (
 	"os/exec"
 	"testing"
 
+	"github.com/jesseduffield/lazygit/pkg/commands/models"
 	"github.com/jesseduffield/lazygit/pkg/commands/oscommands"
 	"github.com/jesseduffield/lazygit/pkg/i18n"
 	"github.com/jesseduffield/lazygit/pkg/utils"
@@ -111,3 +112,18 @@ func TestCommitListBuilderGetMergeBase(t *testing.T) {
 		})
 	}
 }
+
+// TestCommitListBuilderGetInteractiveRebasingCommits is a function.
+func TestCommitListBuilderGetInteractiveRebasingCommits(t *testing.T) {
+	c := NewDummyCommitListBuilder()
+	c.GitCommand = NewDummyGitCommandWithFiles(map[string]string{
+		".git/rebase-merge/git-rebase-todo": "pick 1234567 first commit\n# a comment\nedit 89abcde second commit\n",
+	})
+
+	commits, err := c.getInteractiveRebasingCommits()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*models.Commit{
+		{Sha: "89abcde", Name: "second commit", Status: "rebasing", Action: "edit"},
+		{Sha: "1234567", Name: "first commit", Status: "rebasing", Action: "pick"},
+	}, commits)
+}
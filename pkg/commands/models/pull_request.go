@@ -0,0 +1,50 @@
+package models
+
+import "fmt"
+
+// states a pull request can be in, normalised across git services
+const (
+	PullRequestStateOpen   = "open"
+	PullRequestStateClosed = "closed"
+	PullRequestStateMerged = "merged"
+)
+
+// PullRequest : A pull (or merge) request on the remote git service
+type PullRequest struct {
+	Number       int
+	Title        string
+	State        string // one of "open", "closed" or "merged"
+	Author       string
+	SourceBranch string
+	TargetBranch string
+	URL          string
+}
+
+// NewPullRequest creates a PullRequest
+func NewPullRequest(number int, title string, state string, author string, sourceBranch string, targetBranch string, url string) *PullRequest {
+	return &PullRequest{
+		Number:       number,
+		Title:        title,
+		State:        state,
+		Author:       author,
+		SourceBranch: sourceBranch,
+		TargetBranch: targetBranch,
+		URL:          url,
+	}
+}
+
+func (p *PullRequest) IsOpen() bool {
+	return p.State == PullRequestStateOpen
+}
+
+func (p *PullRequest) RefName() string {
+	return p.SourceBranch
+}
+
+func (p *PullRequest) ID() string {
+	return fmt.Sprintf("#%d", p.Number)
+}
+
+func (p *PullRequest) Description() string {
+	return fmt.Sprintf("#%d %s", p.Number, p.Title)
+}
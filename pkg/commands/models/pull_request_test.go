@@ -0,0 +1,46 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewPullRequest is a function.
+func TestNewPullRequest(t *testing.T) {
+	pullRequest := NewPullRequest(12, "Add sum operation", PullRequestStateOpen, "peter", "feature/sum-operation", "master", "https://github.com/peter/calculator/pull/12")
+
+	assert.EqualValues(t, &PullRequest{
+		Number:       12,
+		Title:        "Add sum operation",
+		State:        "open",
+		Author:       "peter",
+		SourceBranch: "feature/sum-operation",
+		TargetBranch: "master",
+		URL:          "https://github.com/peter/calculator/pull/12",
+	}, pullRequest)
+	assert.True(t, pullRequest.IsOpen())
+	assert.Equal(t, "feature/sum-operation", pullRequest.RefName())
+	assert.Equal(t, "#12", pullRequest.ID())
+	assert.Equal(t, "#12 Add sum operation", pullRequest.Description())
+}
+
+// TestPullRequestIsOpen is a function.
+func TestPullRequestIsOpen(t *testing.T) {
+	type scenario struct {
+		state    string
+		expected bool
+	}
+
+	scenarios := []scenario{
+		{PullRequestStateOpen, true},
+		{PullRequestStateClosed, false},
+		{PullRequestStateMerged, false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.state, func(t *testing.T) {
+			assert.Equal(t, s.expected, (&PullRequest{State: s.state}).IsOpen())
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package oscommands
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/go-errors/errors"
+)
+
+// CommandRunner runs the commands built by OSCommand. Swap it out in tests to
+// avoid running anything for real
+type CommandRunner interface {
+	// CombinedOutput runs the command, returning its stdout and stderr combined
+	CombinedOutput(cmd *exec.Cmd) ([]byte, error)
+}
+
+type execCommandRunner struct{}
+
+func (execCommandRunner) CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	return cmd.CombinedOutput()
+}
+
+type fakeCommandResult struct {
+	command string
+	output  string
+	err     error
+}
+
+// FakeCommandRunner is a CommandRunner for tests. It records the commands it's
+// asked to run and answers each of them with the canned result given to Expect,
+// failing any command it wasn't told to expect
+type FakeCommandRunner struct {
+	results  []fakeCommandResult
+	calls    []string
+	callArgs [][]string
+	mutex    sync.Mutex
+}
+
+// NewFakeCommandRunner creates a FakeCommandRunner which expects no commands
+func NewFakeCommandRunner() *FakeCommandRunner {
+	return &FakeCommandRunner{}
+}
+
+// Expect tells the runner to answer the given command, which is its arguments
+// joined by spaces e.g. 'git status', with the given output and error
+func (r *FakeCommandRunner) Expect(command string, output string, err error) *FakeCommandRunner {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.results = append(r.results, fakeCommandResult{command: command, output: output, err: err})
+	return r
+}
+
+// CombinedOutput returns the canned result of the command without running it
+func (r *FakeCommandRunner) CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	command := strings.Join(cmd.Args, " ")
+	r.calls = append(r.calls, command)
+	r.callArgs = append(r.callArgs, append([]string{}, cmd.Args...))
+
+	for _, result := range r.results {
+		if result.command == command {
+			return []byte(result.output), result.err
+		}
+	}
+
+	return nil, errors.New("unexpected command: " + command)
+}
+
+// Calls returns the commands the runner has been asked to run, in order
+func (r *FakeCommandRunner) Calls() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string{}, r.calls...)
+}
+
+// CallArgs returns the arguments of the commands the runner has been asked to
+// run, in order, for tests which care where one argument ends and the next
+// begins
+func (r *FakeCommandRunner) CallArgs() [][]string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([][]string{}, r.callArgs...)
+}
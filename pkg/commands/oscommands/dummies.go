@@ -7,5 +7,14 @@ import (
 
 // NewDummyOSCommand creates a new dummy OSCommand for testing
 func NewDummyOSCommand() *OSCommand {
-	return NewOSCommand(utils.NewDummyLog(), config.NewDummyAppConfig())
+	osCommand := NewOSCommand(utils.NewDummyLog(), config.NewDummyAppConfig())
+	// so that tests don't depend on what's installed on the machine running them
+	osCommand.LookPath = func(name string) (string, error) {
+		return name, nil
+	}
+	osCommand.IsWSL = func() bool {
+		return false
+	}
+
+	return osCommand
 }
@@ -2,6 +2,7 @@ package oscommands
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-errors/errors"
 
@@ -41,6 +43,16 @@ type OSCommand struct {
 	Command          func(string, ...string) *exec.Cmd
 	BeforeExecuteCmd func(*exec.Cmd)
 	Getenv           func(string) string
+	Getwd            func() (string, error)
+	LookPath         func(string) (string, error)
+	Runner           CommandRunner
+	WriteToClipboard func(string) error
+	IsWSL            func() bool
+
+	// in dry run mode, commands are recorded rather than run
+	dryRun           bool
+	recordedCommands []string
+	recordMutex      sync.Mutex
 }
 
 // NewOSCommand os command runner
@@ -52,9 +64,25 @@ func NewOSCommand(log *logrus.Entry, config config.AppConfigurer) *OSCommand {
 		Command:          exec.Command,
 		BeforeExecuteCmd: func(*exec.Cmd) {},
 		Getenv:           os.Getenv,
+		Getwd:            os.Getwd,
+		LookPath:         exec.LookPath,
+		Runner:           execCommandRunner{},
+		WriteToClipboard: clipboard.WriteAll,
+		IsWSL:            isWSL,
 	}
 }
 
+// isWSL returns true if we're running under the Windows Subsystem for Linux,
+// whose kernel is marked as Microsoft's
+func isWSL() bool {
+	version, err := ioutil.ReadFile("/proc/version")
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(strings.ToLower(string(version)), "microsoft")
+}
+
 // SetCommand sets the command function used by the struct.
 // To be used for testing only
 func (c *OSCommand) SetCommand(cmd func(string, ...string) *exec.Cmd) {
@@ -65,15 +93,105 @@ func (c *OSCommand) SetBeforeExecuteCmd(cmd func(*exec.Cmd)) {
 	c.BeforeExecuteCmd = cmd
 }
 
+// SetDryRun toggles dry run mode, in which commands are recorded rather than
+// run, as if they had succeeded with no output. Interactive and piped commands
+// are unaffected
+func (c *OSCommand) SetDryRun(dryRun bool) {
+	c.dryRun = dryRun
+}
+
+// RecordedCommands returns the commands recorded in dry run mode, in the order
+// they would have been run
+func (c *OSCommand) RecordedCommands() []string {
+	c.recordMutex.Lock()
+	defer c.recordMutex.Unlock()
+
+	return append([]string{}, c.recordedCommands...)
+}
+
+// combinedOutput runs the command and returns its combined output, unless we're
+// in dry run mode in which case it just records the command
+func (c *OSCommand) combinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	if !c.dryRun {
+		return c.Runner.CombinedOutput(cmd)
+	}
+
+	c.recordMutex.Lock()
+	defer c.recordMutex.Unlock()
+
+	c.recordedCommands = append(c.recordedCommands, strings.Join(cmd.Args, " "))
+	return nil, nil
+}
+
 type RunCommandOptions struct {
 	EnvVars []string
+	// Dir is the directory to run the command in. If empty, it's run in ours
+	Dir string
+	// Timeout, if set, is how long we give the command before killing it and
+	// returning an ErrCommandTimeout, for commands which might hang
+	Timeout time.Duration
+}
+
+// ErrCommandTimeout is returned when a command doesn't finish within the
+// timeout it was given
+type ErrCommandTimeout struct {
+	Command string
+	Timeout time.Duration
+}
+
+func (e *ErrCommandTimeout) Error() string {
+	return fmt.Sprintf("'%s' didn't finish within %s", e.Command, e.Timeout)
 }
 
 func (c *OSCommand) RunCommandWithOutputWithOptions(command string, options RunCommandOptions) (string, error) {
+	return c.RunArgsWithOutputWithOptions(str.ToArgv(command), options)
+}
+
+// RunArgsWithOutputWithOptions is like RunCommandWithOutputWithOptions for a
+// command we already have the arguments of, e.g. because some of them are
+// user input like a pull request's body, which we'd otherwise have to quote
+func (c *OSCommand) RunArgsWithOutputWithOptions(args []string, options RunCommandOptions) (string, error) {
+	command := strings.Join(args, " ")
 	c.Log.WithField("command", command).Info("RunCommand")
-	cmd := c.ExecutableFromString(command)
+	cmd := c.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0")
 	cmd.Env = append(cmd.Env, options.EnvVars...)
-	return sanitisedCommandOutput(cmd.CombinedOutput())
+	cmd.Dir = options.Dir
+	if options.Timeout > 0 {
+		return c.combinedOutputWithTimeout(command, cmd, options.Timeout)
+	}
+	return sanitisedCommandOutput(c.combinedOutput(cmd))
+}
+
+// combinedOutputWithTimeout runs the command like combinedOutput, killing it if
+// it's still running after the timeout. We stop waiting for it regardless, as a
+// process it started can keep its output open after it's killed
+func (c *OSCommand) combinedOutputWithTimeout(command string, cmd *exec.Cmd, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	timedCmd := exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
+	timedCmd.Args = cmd.Args
+	timedCmd.Env = cmd.Env
+	timedCmd.Dir = cmd.Dir
+
+	type result struct {
+		output string
+		err    error
+	}
+	done := make(chan result, 1)
+	go utils.Safe(func() {
+		output, err := sanitisedCommandOutput(c.combinedOutput(timedCmd))
+		done <- result{output: output, err: err}
+	})
+
+	select {
+	case result := <-done:
+		return result.output, result.err
+	case <-ctx.Done():
+		c.Log.WithField("command", command).Errorf("timed out after %s", timeout)
+		return "", &ErrCommandTimeout{Command: command, Timeout: timeout}
+	}
 }
 
 func (c *OSCommand) RunCommandWithOptions(command string, options RunCommandOptions) error {
@@ -94,7 +212,7 @@ func (c *OSCommand) RunCommandWithOutput(formatString string, formatArgs ...inte
 	}
 	c.Log.WithField("command", command).Info("RunCommand")
 	cmd := c.ExecutableFromString(command)
-	output, err := sanitisedCommandOutput(cmd.CombinedOutput())
+	output, err := sanitisedCommandOutput(c.combinedOutput(cmd))
 	if err != nil {
 		c.Log.WithField("command", command).Error(err)
 	}
@@ -104,7 +222,7 @@ func (c *OSCommand) RunCommandWithOutput(formatString string, formatArgs ...inte
 // RunExecutableWithOutput runs an executable file and returns its output
 func (c *OSCommand) RunExecutableWithOutput(cmd *exec.Cmd) (string, error) {
 	c.BeforeExecuteCmd(cmd)
-	return sanitisedCommandOutput(cmd.CombinedOutput())
+	return sanitisedCommandOutput(c.combinedOutput(cmd))
 }
 
 // RunExecutable runs an executable file and returns an error if there was one
@@ -190,8 +308,7 @@ func (c *OSCommand) RunDirectCommand(command string) (string, error) {
 	c.Log.WithField("command", command).Info("RunDirectCommand")
 
 	return sanitisedCommandOutput(
-		c.Command(c.Platform.Shell, c.Platform.ShellArg, command).
-			CombinedOutput(),
+		c.combinedOutput(c.Command(c.Platform.Shell, c.Platform.ShellArg, command)),
 	)
 }
 
@@ -216,20 +333,239 @@ func (c *OSCommand) OpenFile(filename string) error {
 	}
 
 	command := utils.ResolvePlaceholderString(commandTemplate, templateValues)
+	if err := c.checkOpenCommandExists(command); err != nil {
+		return err
+	}
+
 	err := c.RunCommand(command)
 	return err
 }
 
-// OpenLink opens a file with the given
+// checkOpenCommandExists gives a friendlier error than exec's if the program
+// the given open command runs isn't on the PATH
+func (c *OSCommand) checkOpenCommandExists(command string) error {
+	if c.dryRun {
+		return nil
+	}
+
+	args := str.ToArgv(command)
+	if len(args) == 0 {
+		return nil
+	}
+
+	if _, err := c.LookPath(args[0]); err != nil {
+		return errors.New(fmt.Sprintf("open command '%s' not found", args[0]))
+	}
+
+	return nil
+}
+
+// matches {{link}} as well as {{.Link}} and its other spellings
+var linkPlaceholderRegexp = regexp.MustCompile(`{{\.?[lL]ink}}`)
+
+// OpenLink opens a link with the configured open link command. The link is
+// quoted so that characters like '&' or spaces survive as a single argument. If
+// the command has no {{link}} placeholder the link is appended to it
 func (c *OSCommand) OpenLink(link string) error {
-	commandTemplate := c.Config.GetUserConfig().OS.OpenLinkCommand
-	templateValues := map[string]string{
-		"link": c.Quote(link),
+	return c.OpenLinkWithOptions(link, OpenLinkOptions{})
+}
+
+// OpenLinkInPrivateWindow is like OpenLink but passes the browser its flag for
+// opening the link in a private window. If the open link command isn't a
+// browser we know the flag of, the link is opened as usual
+func (c *OSCommand) OpenLinkInPrivateWindow(link string) error {
+	return c.OpenLinkWithOptions(link, OpenLinkOptions{PrivateWindow: true})
+}
+
+type OpenLinkOptions struct {
+	// PrivateWindow opens the link as OpenLinkInPrivateWindow does
+	PrivateWindow bool
+	// Dir is the directory to run the open link command in, for scripts which
+	// expect to be run from somewhere in particular. If empty, it's run in ours
+	Dir string
+	// TemplateValues are further placeholders for the open link command, e.g.
+	// the repo the link belongs to. Like the link, values are quoted for you
+	TemplateValues map[string]string
+}
+
+// OpenLinkWithOptions is like OpenLink but with the given options
+func (c *OSCommand) OpenLinkWithOptions(link string, options OpenLinkOptions) error {
+	commandTemplate := c.getOpenLinkCommand()
+	if !linkPlaceholderRegexp.MatchString(commandTemplate) {
+		commandTemplate += " {{link}}"
 	}
 
+	if options.PrivateWindow {
+		commandTemplate = c.addPrivateWindowFlag(commandTemplate)
+	}
+
+	templateValues := map[string]string{}
+	for key, value := range options.TemplateValues {
+		templateValues[key] = c.Quote(value)
+	}
+	quotedLink := c.Quote(link)
+	templateValues["link"] = quotedLink
+	templateValues["Link"] = quotedLink
+
 	command := utils.ResolvePlaceholderString(commandTemplate, templateValues)
-	err := c.RunCommand(command)
-	return err
+	if err := c.checkOpenCommandExists(command); err != nil {
+		return err
+	}
+
+	return c.RunCommandWithOptions(command, RunCommandOptions{Dir: options.Dir})
+}
+
+// proxyEnvVarNames are the environment variables which tools like curl, gh and
+// glab read their proxy settings from
+var proxyEnvVarNames = []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"}
+
+// ProxyEnvVars returns the proxy settings of our environment in the form
+// 'NAME=value', for passing on to a child process which talks to the network
+func (c *OSCommand) ProxyEnvVars() []string {
+	envVars := []string{}
+	for _, name := range proxyEnvVarNames {
+		if value := c.Getenv(name); value != "" {
+			envVars = append(envVars, name+"="+value)
+		}
+	}
+
+	return envVars
+}
+
+// gitPathEnvVarNames are the environment variables with which git can be
+// pointed at a repo from outside of it
+var gitPathEnvVarNames = []string{"GIT_DIR", "GIT_WORK_TREE", "GIT_INDEX_FILE", "GIT_OBJECT_DIRECTORY", "GIT_COMMON_DIR"}
+
+// GitPathEnvVars returns those of git's environment variables which hold paths
+// in the form 'NAME=value', with relative paths made absolute so that they
+// still hold for a child process run in another directory
+func (c *OSCommand) GitPathEnvVars() []string {
+	envVars := []string{}
+	for _, name := range gitPathEnvVarNames {
+		value := c.Getenv(name)
+		if value == "" {
+			continue
+		}
+
+		if !filepath.IsAbs(value) {
+			if cwd, err := c.Getwd(); err == nil {
+				value = filepath.Join(cwd, value)
+			}
+		}
+		envVars = append(envVars, name+"="+value)
+	}
+
+	return envVars
+}
+
+// privateWindowFlags are the flags with which the browsers we know of open a
+// link in a private window, by the name of their executable
+var privateWindowFlags = map[string]string{
+	"firefox":              "--private-window",
+	"firefox-esr":          "--private-window",
+	"librewolf":            "--private-window",
+	"chrome":               "--incognito",
+	"google-chrome":        "--incognito",
+	"google-chrome-stable": "--incognito",
+	"chromium":             "--incognito",
+	"chromium-browser":     "--incognito",
+	"brave":                "--incognito",
+	"brave-browser":        "--incognito",
+	"vivaldi":              "--incognito",
+	"msedge":               "--inprivate",
+	"microsoft-edge":       "--inprivate",
+	"opera":                "--private",
+}
+
+// addPrivateWindowFlag puts the private window flag of the browser the command
+// runs right before the command's link placeholder, given browsers like
+// firefox expect the link to follow it
+func (c *OSCommand) addPrivateWindowFlag(commandTemplate string) string {
+	args := str.ToArgv(commandTemplate)
+	if len(args) == 0 {
+		return commandTemplate
+	}
+
+	browser := strings.TrimSuffix(strings.ToLower(filepath.Base(args[0])), ".exe")
+	flag, ok := c.Config.GetUserConfig().OS.PrivateWindowFlags[browser]
+	if !ok {
+		flag, ok = privateWindowFlags[browser]
+	}
+	if !ok {
+		c.Log.Warnf("don't know how to open a private window with '%s', so opening the link as usual", args[0])
+		return commandTemplate
+	}
+
+	placeholderIndex := linkPlaceholderRegexp.FindStringIndex(commandTemplate)[0]
+	return commandTemplate[:placeholderIndex] + flag + " " + commandTemplate[placeholderIndex:]
+}
+
+// wslOpeners are the commands which open a link in the Windows browser from
+// WSL, where xdg-open often fails, in order of preference
+var wslOpeners = []string{"wslview", "wsl-open"}
+
+// getOpenLinkCommand returns the configured command for opening links, falling
+// back to $BROWSER, then under WSL to whichever of wslOpeners is installed, and
+// then to the platform's default
+func (c *OSCommand) getOpenLinkCommand() string {
+	if commandTemplate := c.Config.GetUserConfig().OS.OpenLinkCommand.ForPlatform(c.Platform.OS); commandTemplate != "" {
+		return commandTemplate
+	}
+
+	if browser := c.getBrowserCommand(); browser != "" {
+		return browser
+	}
+
+	if c.Platform.OS == "linux" && c.IsWSL() {
+		for _, opener := range wslOpeners {
+			if _, err := c.LookPath(opener); err == nil {
+				return opener + " {{link}}"
+			}
+		}
+	}
+
+	return c.Platform.OpenLinkCommand
+}
+
+// getBrowserCommand returns the command for opening links which $BROWSER asks
+// for, or an empty string if it's unset. As is conventional it's a list of
+// commands, separated like $PATH, of which we take the first that's installed.
+// A '%s' in the command stands for the link, which is otherwise appended
+func (c *OSCommand) getBrowserCommand() string {
+	separator := ":"
+	if c.Platform.OS == "windows" {
+		separator = ";"
+	}
+
+	for _, browser := range strings.Split(c.Getenv("BROWSER"), separator) {
+		fields := strings.Fields(browser)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := c.LookPath(fields[0]); err != nil {
+			continue
+		}
+
+		if !strings.Contains(browser, "%s") {
+			return strings.TrimSpace(browser) + " {{link}}"
+		}
+		// we quote the link ourselves, so quotes around the %s would be doubled
+		replacer := strings.NewReplacer(`'%s'`, "{{link}}", `"%s"`, "{{link}}", "%s", "{{link}}")
+		return strings.TrimSpace(replacer.Replace(browser))
+	}
+
+	return ""
+}
+
+func defaultOpenLinkCommand(goos string) string {
+	switch goos {
+	case "windows":
+		return `cmd /c "start "" {{link}}"`
+	case "linux":
+		return `sh -c "xdg-open {{link}} >/dev/null"`
+	default:
+		return "open {{link}}"
+	}
 }
 
 // PrepareSubProcess iniPrepareSubProcessrocess then tells the Gui to switch to it
@@ -330,7 +666,7 @@ func (c *OSCommand) FileExists(path string) (bool, error) {
 // before running it
 func (c *OSCommand) RunPreparedCommand(cmd *exec.Cmd) error {
 	c.BeforeExecuteCmd(cmd)
-	out, err := cmd.CombinedOutput()
+	out, err := c.combinedOutput(cmd)
 	outString := string(out)
 	c.Log.Info(outString)
 	if err != nil {
@@ -454,5 +790,5 @@ func RunLineOutputCmd(cmd *exec.Cmd, onLine func(line string) (bool, error)) err
 }
 
 func (c *OSCommand) CopyToClipboard(str string) error {
-	return clipboard.WriteAll(str)
+	return c.WriteToClipboard(str)
 }
@@ -14,7 +14,7 @@ func getPlatform() *Platform {
 		ShellArg:             "-c",
 		EscapedQuote:         "'",
 		OpenCommand:          "open {{filename}}",
-		OpenLinkCommand:      "open {{link}}",
+		OpenLinkCommand:      defaultOpenLinkCommand(runtime.GOOS),
 		FallbackEscapedQuote: "\"",
 	}
 }
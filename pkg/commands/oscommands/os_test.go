@@ -5,7 +5,10 @@ import (
 	"os"
 	"os/exec"
 	"testing"
+	"time"
 
+	"github.com/go-errors/errors"
+	"github.com/jesseduffield/lazygit/pkg/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -58,6 +61,76 @@ func TestOSCommandRunCommand(t *testing.T) {
 	}
 }
 
+// TestOSCommandDryRun is a function.
+func TestOSCommandDryRun(t *testing.T) {
+	OSCmd := NewDummyOSCommand()
+	OSCmd.SetDryRun(true)
+
+	output, err := OSCmd.RunCommandWithOutput("rmdir unexisting-folder")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "", output)
+	assert.NoError(t, OSCmd.RunCommand("git push %s %s", "origin", "master"))
+
+	assert.EqualValues(t, []string{"rmdir unexisting-folder", "git push origin master"}, OSCmd.RecordedCommands())
+}
+
+// TestOSCommandRunCommandWithTimeout is a function.
+func TestOSCommandRunCommandWithTimeout(t *testing.T) {
+	type scenario struct {
+		testName string
+		command  string
+		test     func(string, error)
+	}
+
+	scenarios := []scenario{
+		{
+			"Returns the output of a command which finishes in time",
+			"echo -n done",
+			func(output string, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "done", output)
+			},
+		},
+		{
+			"Gives up on a command which runs past the timeout",
+			"sleep 5",
+			func(output string, err error) {
+				timeoutErr, ok := err.(*ErrCommandTimeout)
+				assert.True(t, ok)
+				assert.EqualValues(t, "sleep 5", timeoutErr.Command)
+				assert.EqualError(t, err, "'sleep 5' didn't finish within 100ms")
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			OSCmd := NewDummyOSCommand()
+
+			start := time.Now()
+			s.test(OSCmd.RunCommandWithOutputWithOptions(s.command, RunCommandOptions{Timeout: 100 * time.Millisecond}))
+			assert.True(t, time.Since(start) < 5*time.Second)
+		})
+	}
+}
+
+// TestOSCommandFakeCommandRunner is a function.
+func TestOSCommandFakeCommandRunner(t *testing.T) {
+	OSCmd := NewDummyOSCommand()
+	runner := NewFakeCommandRunner().
+		Expect("git rev-parse HEAD", "abc1234\n", nil).
+		Expect("git push origin master", "rejected", errors.New("exit status 1"))
+	OSCmd.Runner = runner
+
+	output, err := OSCmd.RunCommandWithOutput("git rev-parse HEAD")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "abc1234\n", output)
+	assert.EqualError(t, OSCmd.RunCommand("git push %s %s", "origin", "master"), "rejected")
+	assert.EqualError(t, OSCmd.RunCommand("git fetch"), "unexpected command: git fetch")
+
+	assert.EqualValues(t, []string{"git rev-parse HEAD", "git push origin master", "git fetch"}, runner.Calls())
+}
+
 // TestOSCommandOpenFile is a function.
 func TestOSCommandOpenFile(t *testing.T) {
 	type scenario struct {
@@ -109,6 +182,424 @@ func TestOSCommandOpenFile(t *testing.T) {
 	}
 }
 
+// TestOSCommandOpenLink is a function.
+func TestOSCommandOpenLink(t *testing.T) {
+	type scenario struct {
+		testName        string
+		openLinkCommand string
+		browser         string
+		platform        *Platform
+		command         func(string, ...string) *exec.Cmd
+		test            func(error)
+	}
+
+	scenarios := []scenario{
+		{
+			testName:        "Uses the configured open link command",
+			openLinkCommand: "open {{link}}",
+			browser:         "firefox",
+			platform:        &Platform{OS: "darwin", EscapedQuote: "'", OpenLinkCommand: defaultOpenLinkCommand("darwin")},
+			command: func(name string, arg ...string) *exec.Cmd {
+				assert.Equal(t, "open", name)
+				assert.Equal(t, []string{"https://example.com"}, arg)
+				return exec.Command("echo")
+			},
+			test: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			testName:        "Falls back to $BROWSER when no open link command is configured",
+			openLinkCommand: "",
+			browser:         "firefox",
+			platform:        &Platform{OS: "linux", EscapedQuote: "'", OpenLinkCommand: defaultOpenLinkCommand("linux")},
+			command: func(name string, arg ...string) *exec.Cmd {
+				assert.Equal(t, "firefox", name)
+				assert.Equal(t, []string{"https://example.com"}, arg)
+				return exec.Command("echo")
+			},
+			test: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			testName:        "Puts the link where $BROWSER has a %s",
+			openLinkCommand: "",
+			browser:         "firefox --new-tab '%s' --no-remote",
+			platform:        &Platform{OS: "linux", EscapedQuote: "'", OpenLinkCommand: defaultOpenLinkCommand("linux")},
+			command: func(name string, arg ...string) *exec.Cmd {
+				assert.Equal(t, "firefox", name)
+				assert.Equal(t, []string{"--new-tab", "https://example.com", "--no-remote"}, arg)
+				return exec.Command("echo")
+			},
+			test: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			testName:        "Splits $BROWSER on semicolons on windows",
+			openLinkCommand: "",
+			browser:         "firefox.exe;chrome.exe",
+			platform:        &Platform{OS: "windows", EscapedQuote: "'", OpenLinkCommand: defaultOpenLinkCommand("windows")},
+			command: func(name string, arg ...string) *exec.Cmd {
+				assert.Equal(t, "firefox.exe", name)
+				return exec.Command("echo")
+			},
+			test: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			testName:        "Falls back to xdg-open on linux",
+			openLinkCommand: "",
+			browser:         "",
+			platform:        &Platform{OS: "linux", EscapedQuote: "'", OpenLinkCommand: defaultOpenLinkCommand("linux")},
+			command: func(name string, arg ...string) *exec.Cmd {
+				assert.Equal(t, "sh", name)
+				assert.Equal(t, []string{"-c", "xdg-open 'https://example.com' >/dev/null"}, arg)
+				return exec.Command("echo")
+			},
+			test: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			testName:        "Falls back to open on darwin",
+			openLinkCommand: "",
+			browser:         "",
+			platform:        &Platform{OS: "darwin", EscapedQuote: "'", OpenLinkCommand: defaultOpenLinkCommand("darwin")},
+			command: func(name string, arg ...string) *exec.Cmd {
+				assert.Equal(t, "open", name)
+				assert.Equal(t, []string{"https://example.com"}, arg)
+				return exec.Command("echo")
+			},
+			test: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			testName:        "Falls back to start on windows",
+			openLinkCommand: "",
+			browser:         "",
+			platform:        &Platform{OS: "windows", EscapedQuote: "'", OpenLinkCommand: defaultOpenLinkCommand("windows")},
+			command: func(name string, arg ...string) *exec.Cmd {
+				assert.Equal(t, "cmd", name)
+				assert.Equal(t, "/c", arg[0])
+				assert.Contains(t, arg[1], "start")
+				assert.Contains(t, arg[1], "'https://example.com'")
+				return exec.Command("echo")
+			},
+			test: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			OSCmd := NewDummyOSCommand()
+			OSCmd.Command = s.command
+			OSCmd.Platform = s.platform
+			OSCmd.Getenv = func(key string) string {
+				assert.Equal(t, "BROWSER", key)
+				return s.browser
+			}
+			OSCmd.Config.GetUserConfig().OS.OpenLinkCommand = config.PlatformCommand{Command: s.openLinkCommand}
+
+			s.test(OSCmd.OpenLink("https://example.com"))
+		})
+	}
+}
+
+// TestOSCommandOpenLinkPerPlatform is a function.
+func TestOSCommandOpenLinkPerPlatform(t *testing.T) {
+	type scenario struct {
+		testName     string
+		goos         string
+		expectedName string
+		expectedArgs []string
+	}
+
+	scenarios := []scenario{
+		{
+			testName:     "Uses the command configured for linux",
+			goos:         "linux",
+			expectedName: "xdg-open",
+			expectedArgs: []string{"https://example.com"},
+		},
+		{
+			testName:     "Uses the command configured for darwin",
+			goos:         "darwin",
+			expectedName: "open",
+			expectedArgs: []string{"-a", "Safari", "https://example.com"},
+		},
+		{
+			testName:     "Falls back to $BROWSER for a platform without a command",
+			goos:         "windows",
+			expectedName: "firefox",
+			expectedArgs: []string{"https://example.com"},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			OSCmd := NewDummyOSCommand()
+			OSCmd.Platform = &Platform{OS: s.goos, EscapedQuote: "'", OpenLinkCommand: defaultOpenLinkCommand(s.goos)}
+			OSCmd.Getenv = func(key string) string {
+				return "firefox"
+			}
+			OSCmd.Command = func(name string, arg ...string) *exec.Cmd {
+				assert.Equal(t, s.expectedName, name)
+				assert.Equal(t, s.expectedArgs, arg)
+				return exec.Command("echo")
+			}
+			OSCmd.Config.GetUserConfig().OS.OpenLinkCommand = config.PlatformCommand{
+				ByPlatform: map[string]string{
+					"linux":  "xdg-open {{link}}",
+					"darwin": "open -a Safari {{link}}",
+				},
+			}
+
+			assert.NoError(t, OSCmd.OpenLink("https://example.com"))
+		})
+	}
+}
+
+// TestOSCommandOpenLinkUnderWSL is a function.
+func TestOSCommandOpenLinkUnderWSL(t *testing.T) {
+	type scenario struct {
+		testName        string
+		isWSL           bool
+		installed       []string
+		browser         string
+		openLinkCommand string
+		expectedName    string
+	}
+
+	scenarios := []scenario{
+		{
+			testName:     "Prefers wslview under WSL",
+			isWSL:        true,
+			installed:    []string{"wslview", "wsl-open", "sh"},
+			expectedName: "wslview",
+		},
+		{
+			testName:     "Falls back to wsl-open under WSL",
+			isWSL:        true,
+			installed:    []string{"wsl-open", "sh"},
+			expectedName: "wsl-open",
+		},
+		{
+			testName:     "Falls back to xdg-open under WSL without either",
+			isWSL:        true,
+			installed:    []string{"sh"},
+			expectedName: "sh",
+		},
+		{
+			testName:     "Uses xdg-open outside of WSL",
+			installed:    []string{"wslview", "sh"},
+			expectedName: "sh",
+		},
+		{
+			testName:     "Prefers $BROWSER under WSL",
+			isWSL:        true,
+			installed:    []string{"wslview", "firefox"},
+			browser:      "firefox",
+			expectedName: "firefox",
+		},
+		{
+			testName:     "Takes the first installed browser of a list in $BROWSER",
+			installed:    []string{"chromium"},
+			browser:      "firefox:chromium --new-window",
+			expectedName: "chromium",
+		},
+		{
+			testName:     "Falls back to xdg-open when no browser in $BROWSER is installed",
+			installed:    []string{"sh"},
+			browser:      "firefox:chromium",
+			expectedName: "sh",
+		},
+		{
+			testName:        "Prefers the open link command under WSL",
+			isWSL:           true,
+			installed:       []string{"wslview", "explorer.exe"},
+			openLinkCommand: "explorer.exe {{link}}",
+			expectedName:    "explorer.exe",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			OSCmd := NewDummyOSCommand()
+			OSCmd.Platform = &Platform{OS: "linux", EscapedQuote: "'", OpenLinkCommand: defaultOpenLinkCommand("linux")}
+			OSCmd.IsWSL = func() bool {
+				return s.isWSL
+			}
+			OSCmd.LookPath = func(name string) (string, error) {
+				for _, installed := range s.installed {
+					if name == installed {
+						return "/usr/bin/" + name, nil
+					}
+				}
+				return "", errors.New("not found")
+			}
+			OSCmd.Getenv = func(key string) string {
+				if key == "BROWSER" {
+					return s.browser
+				}
+				return ""
+			}
+			OSCmd.Command = func(name string, arg ...string) *exec.Cmd {
+				assert.Equal(t, s.expectedName, name)
+				return exec.Command("echo")
+			}
+			OSCmd.Config.GetUserConfig().OS.OpenLinkCommand = config.PlatformCommand{Command: s.openLinkCommand}
+
+			assert.NoError(t, OSCmd.OpenLink("https://example.com"))
+		})
+	}
+}
+
+// TestOSCommandOpenLinkInPrivateWindow is a function.
+func TestOSCommandOpenLinkInPrivateWindow(t *testing.T) {
+	type scenario struct {
+		testName           string
+		openLinkCommand    string
+		privateWindowFlags map[string]string
+		expectedName       string
+		expectedArgs       []string
+	}
+
+	scenarios := []scenario{
+		{
+			testName:     "Passes firefox its flag from $BROWSER",
+			expectedName: "firefox",
+			expectedArgs: []string{"--private-window", "https://example.com"},
+		},
+		{
+			testName:        "Passes chrome its flag before the link",
+			openLinkCommand: "/usr/bin/google-chrome --new-window {{link}} --no-first-run",
+			expectedName:    "/usr/bin/google-chrome",
+			expectedArgs:    []string{"--new-window", "--incognito", "https://example.com", "--no-first-run"},
+		},
+		{
+			testName:        "Passes a configured browser its configured flag",
+			openLinkCommand: "waterfox",
+			privateWindowFlags: map[string]string{
+				"waterfox": "--private-window",
+			},
+			expectedName: "waterfox",
+			expectedArgs: []string{"--private-window", "https://example.com"},
+		},
+		{
+			testName:        "Opens the link as usual with an unknown browser",
+			openLinkCommand: "xdg-open {{link}}",
+			expectedName:    "xdg-open",
+			expectedArgs:    []string{"https://example.com"},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			OSCmd := NewDummyOSCommand()
+			OSCmd.Getenv = func(key string) string {
+				return "firefox"
+			}
+			OSCmd.Command = func(name string, arg ...string) *exec.Cmd {
+				assert.Equal(t, s.expectedName, name)
+				assert.Equal(t, s.expectedArgs, arg)
+				return exec.Command("echo")
+			}
+			OSCmd.Config.GetUserConfig().OS.OpenLinkCommand = config.PlatformCommand{Command: s.openLinkCommand}
+			OSCmd.Config.GetUserConfig().OS.PrivateWindowFlags = s.privateWindowFlags
+
+			assert.NoError(t, OSCmd.OpenLinkInPrivateWindow("https://example.com"))
+		})
+	}
+}
+
+// TestOSCommandOpenCommandNotFound is a function.
+func TestOSCommandOpenCommandNotFound(t *testing.T) {
+	OSCmd := NewDummyOSCommand()
+	OSCmd.LookPath = func(name string) (string, error) {
+		assert.Equal(t, "firefox", name)
+		return "", errors.New("executable file not found in $PATH")
+	}
+	OSCmd.Command = func(name string, arg ...string) *exec.Cmd {
+		t.Fatalf("expected no command to be run, got %s %v", name, arg)
+		return nil
+	}
+	OSCmd.Config.GetUserConfig().OS.OpenLinkCommand = config.PlatformCommand{Command: "firefox --new-tab {{link}}"}
+	OSCmd.Config.GetUserConfig().OS.OpenCommand = "firefox {{filename}}"
+
+	assert.EqualError(t, OSCmd.OpenLink("https://example.com"), "open command 'firefox' not found")
+	assert.EqualError(t, OSCmd.OpenFile("notes.txt"), "open command 'firefox' not found")
+}
+
+// TestOSCommandOpenLinkArgs is a function.
+func TestOSCommandOpenLinkArgs(t *testing.T) {
+	type scenario struct {
+		testName        string
+		openLinkCommand string
+		link            string
+		expectedName    string
+		expectedArgs    []string
+	}
+
+	scenarios := []scenario{
+		{
+			testName:        "Keeps ampersands in the link",
+			openLinkCommand: "open {{link}}",
+			link:            "https://bitbucket.org/johndoe/social_network/pull-requests/new?source=feature/profile-page&t=1",
+			expectedName:    "open",
+			expectedArgs:    []string{"https://bitbucket.org/johndoe/social_network/pull-requests/new?source=feature/profile-page&t=1"},
+		},
+		{
+			testName:        "Keeps a link with spaces as a single argument",
+			openLinkCommand: "open {{link}}",
+			link:            "https://example.com/search?q=two words&page=1",
+			expectedName:    "open",
+			expectedArgs:    []string{"https://example.com/search?q=two words&page=1"},
+		},
+		{
+			testName:        "Supports the {{.Link}} placeholder and extra arguments",
+			openLinkCommand: "firefox -P work --new-tab {{.Link}}",
+			link:            "https://example.com/?a=1&b=2",
+			expectedName:    "firefox",
+			expectedArgs:    []string{"-P", "work", "--new-tab", "https://example.com/?a=1&b=2"},
+		},
+		{
+			testName:        "Appends the link when there is no placeholder",
+			openLinkCommand: "firefox --private-window",
+			link:            "https://example.com/?a=1&b=2",
+			expectedName:    "firefox",
+			expectedArgs:    []string{"--private-window", "https://example.com/?a=1&b=2"},
+		},
+		{
+			testName:        "Passes the quoted link on to a shell",
+			openLinkCommand: `sh -c "xdg-open {{link}} >/dev/null"`,
+			link:            "https://example.com/?a=1&b=two words",
+			expectedName:    "sh",
+			expectedArgs:    []string{"-c", "xdg-open 'https://example.com/?a=1&b=two words' >/dev/null"},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			OSCmd := NewDummyOSCommand()
+			OSCmd.Platform = &Platform{OS: "linux", EscapedQuote: "'", FallbackEscapedQuote: "\""}
+			OSCmd.Command = func(name string, arg ...string) *exec.Cmd {
+				assert.Equal(t, s.expectedName, name)
+				assert.Equal(t, s.expectedArgs, arg)
+				return exec.Command("echo")
+			}
+			OSCmd.Config.GetUserConfig().OS.OpenLinkCommand = config.PlatformCommand{Command: s.openLinkCommand}
+
+			assert.NoError(t, OSCmd.OpenLink(s.link))
+		})
+	}
+}
+
 // TestOSCommandQuote is a function.
 func TestOSCommandQuote(t *testing.T) {
 	osCommand := NewDummyOSCommand()
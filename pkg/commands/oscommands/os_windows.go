@@ -7,6 +7,7 @@ func getPlatform() *Platform {
 		Shell:                "cmd",
 		ShellArg:             "/c",
 		EscapedQuote:         `\"`,
+		OpenLinkCommand:      defaultOpenLinkCommand("windows"),
 		FallbackEscapedQuote: "\\'",
 	}
 }
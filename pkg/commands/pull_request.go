@@ -1,18 +1,248 @@
 package commands
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-errors/errors"
 	"github.com/jesseduffield/lazygit/pkg/commands/models"
+	"github.com/jesseduffield/lazygit/pkg/commands/oscommands"
 	"github.com/jesseduffield/lazygit/pkg/config"
+	"github.com/jesseduffield/lazygit/pkg/utils"
 )
 
+const azureDevOpsDomain = "dev.azure.com"
+
+// AWS CodeCommit remotes live on a per-region host like
+// git-codecommit.us-east-1.amazonaws.com
+const (
+	codeCommitDomain     = "amazonaws.com"
+	codeCommitHostPrefix = "git-codecommit."
+	codeCommitConsole    = "console.aws.amazon.com"
+)
+
+// Launchpad serves git over git+ssh://git.launchpad.net/<project> but has
+// merge proposals rather than pull requests, so we only point people at the
+// repo's page on its web domain
+const (
+	launchpadDomain    = "git.launchpad.net"
+	launchpadWebDomain = "code.launchpad.net"
+)
+
+// if a remote with this name exists, we treat the origin remote as a fork of it
+const upstreamRemoteName = "upstream"
+
+// ErrUnsupportedGitService is returned when a remote's host doesn't belong to
+// any git service we know, so that callers can suggest adding it to the
+// Services config
+type ErrUnsupportedGitService struct {
+	Host    string
+	message string
+}
+
+func (e *ErrUnsupportedGitService) Error() string {
+	return e.message
+}
+
+// ErrDefaultBranchPullRequest is returned when a pull request would merge the
+// repo's default branch into itself, which is almost always a mistake. It's
+// only a warning: callers can set PullRequestOptions.AllowDefaultBranch to
+// open the pull request anyway
+type ErrDefaultBranchPullRequest struct {
+	Branch  string
+	message string
+}
+
+func (e *ErrDefaultBranchPullRequest) Error() string {
+	return e.message
+}
+
+// ErrPostCreateCommand is returned along with the link to a pull request when
+// PR.PostCreateCommand fails after we've opened it. The pull request itself is
+// fine, so callers should treat it as a success and just report the error
+type ErrPostCreateCommand struct {
+	Command string
+	message string
+}
+
+func (e *ErrPostCreateCommand) Error() string {
+	return e.message
+}
+
 // Service is a service that repository is on (Github, Bitbucket, ...)
 type Service struct {
-	Name           string
-	PullRequestURL string
+	// Type is the provider of the service, e.g. 'github', or empty for services
+	// only defined by a PullRequestURLTemplate
+	Type                           string
+	Name                           string
+	Host                           string
+	PullRequestURL                 string
+	PullRequestURLIntoTargetBranch string
+	RepoURL                        string
+	CommitURL                      string
+
+	// PullRequestNumberURL links to the existing pull request with the given
+	// {{number}}
+	PullRequestNumberURL string
+
+	// CompareURL links to the comparison of two branches, with {{targetBranch}}
+	// as the base and {{branch}} as the head
+	CompareURL string
+
+	// FileURL links to a file at a commit. LineAnchor or LineRangeAnchor are
+	// appended to it to highlight a single line or a range of lines respectively
+	FileURL         string
+	LineAnchor      string
+	LineRangeAnchor string
+
+	// BlameURL links to the blame of a file at a commit
+	BlameURL string
+
+	// NewIssueURL links to the form for opening a new issue
+	NewIssueURL string
+
+	// PipelinesURL links to the CI runs (e.g. GitHub Actions) of a branch
+	PipelinesURL string
+
+	// TagURL links to the page of a tag, which is its release where the service
+	// has releases
+	TagURL string
+
+	// PullRequestURLTemplate is a user-supplied go template which, if set, is used
+	// instead of the URLs above
+	PullRequestURLTemplate string
+
+	// FormParam, if set, is appended to the pull request URL to go straight to
+	// the pull request form rather than to the comparison of the branches. It's
+	// skipped if the user has turned off PR.AppendFormParam
+	FormParam string
+
+	// DraftPullRequestParam, if set, is appended to the pull request URL to open
+	// the pull request as a draft
+	DraftPullRequestParam string
+
+	// TitleParam and BodyParam, if set, are appended to the pull request URL to
+	// prefill the pull request's title and body
+	TitleParam string
+	BodyParam  string
+
+	// DraftTitlePrefix, if set, marks a draft pull request in place of
+	// DraftPullRequestParam when we prefill the title, for services like GitLab
+	// whose drafts are just pull requests with a 'Draft:' title
+	DraftTitlePrefix string
+
+	// TargetProjectIDParam, if set, is appended to the pull request URL to open
+	// a merge request from a fork into another project, given the target
+	// project's numeric ID. The service's form only takes an ID, so a project's
+	// path is looked up with ProjectIDCmd, which prints the project at
+	// {{projectPath}} as json
+	TargetProjectIDParam string
+	ProjectIDCmd         string
+
+	// DefaultBase, if set, is the branch the service's pull requests are based
+	// on when we aren't told one, e.g. for a Bitbucket Data Center instance which
+	// only lets pull requests into a particular branch. It's set from
+	// PR.DefaultBaseByDomain
+	DefaultBase string
+
+	// BasePath is the path the service is hosted under on its web domain, if
+	// any, e.g. 'gitlab' for an instance at corp.net/gitlab. It's part of the
+	// URLs above already, so we drop it from the owner of any remote containing it
+	BasePath string
+
+	// RequiresTargetBranch is true for services which can't compare a branch
+	// against the repo's default branch without being told what it is, in which
+	// case we look it up ourselves and use PullRequestURLIntoTargetBranch
+	RequiresTargetBranch bool
+
+	// UsesArcDiff is true for Phabricator, which has revisions created with
+	// 'arc diff' rather than pull requests opened in the browser
+	UsesArcDiff bool
+
+	// SupportsForks is true for services which can open a pull request against an
+	// upstream repo from a fork, using a '<fork-owner>:<branch>' head
+	SupportsForks bool
+
+	// normaliseRepoInfo, if set, adjusts the repo information parsed from a remote
+	// url to fit the service's url layout
+	normaliseRepoInfo func(*RepoInformation) *RepoInformation
+
+	// matchesHost, if set, narrows down which hosts under the git domain belong
+	// to the service, for services sharing their domain with others
+	matchesHost func(host string) bool
+
+	// CreatePullRequestAPIURL, if set, is the REST endpoint we create pull
+	// requests at rather than opening the web form, once the user has given us
+	// credentials for it in PR.BitbucketAPI
+	CreatePullRequestAPIURL string
+
+	// CreatePullRequestCmd, if set, creates a pull request of {{branch}} via the
+	// service's CLI. It's used for options only the CLI supports, like reviewers,
+	// or when PR.UseCLIWhenAvailable is set. CreatePullRequestTargetFlag and
+	// CreatePullRequestBodyFlag are the CLI's flags for the target branch and the
+	// body, which default to gh's
+	CreatePullRequestCmd        string
+	CreatePullRequestTargetFlag string
+	CreatePullRequestBodyFlag   string
+
+	// ListPullRequestsCmd, if set, lists the repo's open pull requests as json via
+	// the service's CLI, whose output parsePullRequests then turns into models
+	ListPullRequestsCmd string
+	parsePullRequests   func(output string) ([]*models.PullRequest, error)
+}
+
+// pullRequestURLTemplateArgs holds the values available to a PullRequestURLTemplate
+type pullRequestURLTemplateArgs struct {
+	Owner        string
+	Project      string
+	Repository   string
+	Branch       string
+	TargetBranch string
+	Host         string
+	Draft        bool
+	Title        string
+	Body         string
+}
+
+// PullRequestOptions are the optional settings of a new pull request
+type PullRequestOptions struct {
+	// Target is the branch to merge into. See CreateWithTarget for the default
+	Target string
+	// Draft opens the pull request as a draft
+	Draft bool
+	// Title and Body prefill the pull request form on services which support it
+	// and are ignored elsewhere
+	Title string
+	Body  string
+	// repoConfig is the repo's RepoConfigFilename, which we load once per pull
+	// request rather than whenever we work out its target. Nil until loaded
+	repoConfig *config.RepoConfig
+	// TargetProject is the numeric ID or the path (e.g. 'mathcorp/calculator') of
+	// the project to merge into, on services with cross-project merge requests.
+	// A path is looked up with the service's CLI. If empty, the upstream remote's
+	// project is used if it differs from ours
+	TargetProject string
+	// Reviewers and Labels can only be set through the CLI of the service, so
+	// when either is given and the CLI is installed we create the pull request
+	// with it, as we do for any pull request if PR.UseCLIWhenAvailable is set.
+	// Otherwise they're dropped and we open the web form as usual
+	Reviewers []string
+	Labels    []string
+	// AllowDefaultBranch opens a pull request of the default branch into itself
+	// rather than returning an ErrDefaultBranchPullRequest
+	AllowDefaultBranch bool
 }
 
 // PullRequest opens a link in browser to create new pull request
@@ -20,145 +250,2175 @@ type Service struct {
 type PullRequest struct {
 	GitServices []*Service
 	GitCommand  *GitCommand
+
+	// RemoteName is the remote whose url we build pull requests from. If empty,
+	// the branch's upstream remote is used, falling back to origin
+	RemoteName string
+
+	// sleep waits between attempts at opening a pull request
+	sleep func(time.Duration)
+
+	// httpClient sends the requests to services' REST APIs
+	httpClient *http.Client
 }
 
 // RepoInformation holds some basic information about the repo
 type RepoInformation struct {
-	Owner      string
+	// Owner may span several path segments, e.g. 'group/subgroup' for nested GitLab
+	// groups. On sourcehut it includes the leading tilde, e.g. '~user'
+	Owner string
+	// Project is only used by services with a three-level hierarchy like Azure DevOps
+	Project    string
 	Repository string
 }
 
-// NewService builds a Service based on the host type
-func NewService(typeName string, repositoryDomain string, siteDomain string) *Service {
-	var service *Service
+// serviceTypes holds the function building a Service of each type for the given
+// domains, keyed by the name of the type
+var serviceTypes = map[string]func(repositoryDomain string, siteDomain string) *Service{}
 
-	switch typeName {
-	case "github":
-		service = &Service{
-			Name:           repositoryDomain,
-			PullRequestURL: fmt.Sprintf("https://%s%s", siteDomain, "/%s/%s/compare/%s?expand=1"),
-		}
-	case "bitbucket":
-		service = &Service{
-			Name:           repositoryDomain,
-			PullRequestURL: fmt.Sprintf("https://%s%s", siteDomain, "/%s/%s/pull-requests/new?source=%s&t=1"),
-		}
-	case "gitlab":
-		service = &Service{
-			Name:           repositoryDomain,
-			PullRequestURL: fmt.Sprintf("https://%s%s", siteDomain, "/%s/%s/merge_requests/new?merge_request[source_branch]=%s"),
+func init() {
+	RegisterServiceType("github", newGitHubService)
+	RegisterServiceType("bitbucket", newBitbucketService)
+	RegisterServiceType("bitbucketServer", newBitbucketServerService)
+	RegisterServiceType("gitlab", newGitLabService)
+	RegisterServiceType("gitea", newGiteaService)
+	// forgejo is a fork of gitea with the same urls
+	RegisterServiceType("forgejo", newGiteaService)
+	RegisterServiceType("gogs", newGogsService)
+	RegisterServiceType("sourcehut", newSourcehutService)
+	RegisterServiceType("codecommit", newCodeCommitService)
+	RegisterServiceType("azuredevops", newAzureDevOpsService)
+	RegisterServiceType("phabricator", newPhabricatorService)
+}
+
+// RegisterServiceType makes a type of git service available to NewService, and
+// so to the services config, under the given name
+func RegisterServiceType(typeName string, newService func(repositoryDomain string, siteDomain string) *Service) {
+	if _, ok := serviceTypes[typeName]; !ok && !isConfigServiceProvider(typeName) {
+		config.ServiceProviders = append(config.ServiceProviders, typeName)
+	}
+
+	serviceTypes[typeName] = newService
+}
+
+func isConfigServiceProvider(typeName string) bool {
+	for _, provider := range config.ServiceProviders {
+		if provider == typeName {
+			return true
 		}
 	}
 
+	return false
+}
+
+// NewService builds a Service based on the host type, which is one of
+// config.ServiceProviders
+func NewService(typeName string, repositoryDomain string, siteDomain string) *Service {
+	newService, ok := serviceTypes[typeName]
+	if !ok {
+		return nil
+	}
+
+	// a bare IPv6 address would run into the port of the urls we build
+	repositoryDomain = bracketIPv6(repositoryDomain)
+	siteDomain = bracketIPv6(strings.TrimSuffix(siteDomain, "/"))
+	service := newService(repositoryDomain, siteDomain)
+	service.Type = typeName
+	if pathIndex := strings.Index(siteDomain, "/"); pathIndex != -1 {
+		service.BasePath = siteDomain[pathIndex+1:]
+	}
+
 	return service
 }
 
+func newGitHubService(repositoryDomain string, siteDomain string) *Service {
+	return &Service{
+		Name:                           repositoryDomain,
+		Host:                           siteDomain,
+		PullRequestURL:                 fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/compare/{{branch}}?expand=1"),
+		PullRequestURLIntoTargetBranch: fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/compare/{{targetBranch}}...{{branch}}?expand=1"),
+		RepoURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}"),
+		CommitURL:                      fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/commit/{{sha}}"),
+		PullRequestNumberURL:           fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/pull/{{number}}"),
+		CompareURL:                     fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/compare/{{targetBranch}}...{{branch}}"),
+		FileURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/blob/{{sha}}/{{path}}"),
+		BlameURL:                       fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/blame/{{sha}}/{{path}}"),
+		NewIssueURL:                    fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/issues/new"),
+		PipelinesURL:                   fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/actions?query=branch:{{branch}}"),
+		TagURL:                         fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/releases/tag/{{tag}}"),
+		LineAnchor:                     "#L{{line}}",
+		LineRangeAnchor:                "#L{{startLine}}-L{{endLine}}",
+		DraftPullRequestParam:          "&draft=1",
+		TitleParam:                     "&title={{title}}",
+		BodyParam:                      "&body={{body}}",
+		SupportsForks:                  true,
+		CreatePullRequestCmd:           "gh pr create --repo {{host}}/{{owner}}/{{repository}} --head {{branch}}",
+		ListPullRequestsCmd:            "gh pr list --repo {{host}}/{{owner}}/{{repository}} --json number,title,state,author,headRefName,baseRefName,url",
+		parsePullRequests:              parseGitHubPullRequests,
+	}
+}
+
+func newBitbucketService(repositoryDomain string, siteDomain string) *Service {
+	return &Service{
+		Name:                           repositoryDomain,
+		Host:                           siteDomain,
+		PullRequestURL:                 fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/pull-requests/new?source={{branch}}"),
+		PullRequestURLIntoTargetBranch: fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/pull-requests/new?source={{branch}}&dest={{targetBranch}}"),
+		FormParam:                      "&t=1",
+		RepoURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}"),
+		CommitURL:                      fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/commits/{{sha}}"),
+		PullRequestNumberURL:           fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/pull-requests/{{number}}"),
+		CompareURL:                     fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/branches/compare/{{branch}}%0D{{targetBranch}}"),
+		FileURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/src/{{sha}}/{{path}}"),
+		BlameURL:                       fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/annotate/{{sha}}/{{path}}"),
+		NewIssueURL:                    fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/issues/new"),
+		PipelinesURL:                   fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/pipelines/results/branch/{{branch}}/page/1"),
+		TagURL:                         fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/src/{{tag}}"),
+		LineAnchor:                     "#lines-{{line}}",
+		LineRangeAnchor:                "#lines-{{startLine}}:{{endLine}}",
+		CreatePullRequestAPIURL:        "https://api.bitbucket.org/2.0/repositories/{{owner}}/{{repository}}/pullrequests",
+	}
+}
+
+// bitbucket server repos live in projects, which we treat as the owner
+func newBitbucketServerService(repositoryDomain string, siteDomain string) *Service {
+	return &Service{
+		Name:                           repositoryDomain,
+		Host:                           siteDomain,
+		PullRequestURL:                 fmt.Sprintf("https://%s%s", siteDomain, "/projects/{{owner}}/repos/{{repository}}/pull-requests?create&sourceBranch={{branch}}"),
+		PullRequestURLIntoTargetBranch: fmt.Sprintf("https://%s%s", siteDomain, "/projects/{{owner}}/repos/{{repository}}/pull-requests?create&sourceBranch={{branch}}&targetBranch={{targetBranch}}"),
+		RepoURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/projects/{{owner}}/repos/{{repository}}"),
+		CommitURL:                      fmt.Sprintf("https://%s%s", siteDomain, "/projects/{{owner}}/repos/{{repository}}/commits/{{sha}}"),
+		PullRequestNumberURL:           fmt.Sprintf("https://%s%s", siteDomain, "/projects/{{owner}}/repos/{{repository}}/pull-requests/{{number}}"),
+		CompareURL:                     fmt.Sprintf("https://%s%s", siteDomain, "/projects/{{owner}}/repos/{{repository}}/compare/commits?sourceBranch={{branch}}&targetBranch={{targetBranch}}"),
+		FileURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/projects/{{owner}}/repos/{{repository}}/browse/{{path}}?at={{sha}}"),
+		TagURL:                         fmt.Sprintf("https://%s%s", siteDomain, "/projects/{{owner}}/repos/{{repository}}/browse?at=refs/tags/{{tag}}"),
+		LineAnchor:                     "#{{line}}",
+		LineRangeAnchor:                "#{{startLine}}-{{endLine}}",
+		normaliseRepoInfo:              getBitbucketServerRepoInfo,
+	}
+}
+
+func newGitLabService(repositoryDomain string, siteDomain string) *Service {
+	return &Service{
+		Name:                           repositoryDomain,
+		Host:                           siteDomain,
+		PullRequestURL:                 fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/merge_requests/new?merge_request[source_branch]={{branch}}"),
+		PullRequestURLIntoTargetBranch: fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/merge_requests/new?merge_request[source_branch]={{branch}}&merge_request[target_branch]={{targetBranch}}"),
+		RepoURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}"),
+		CommitURL:                      fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/-/commit/{{sha}}"),
+		PullRequestNumberURL:           fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/-/merge_requests/{{number}}"),
+		CompareURL:                     fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/-/compare/{{targetBranch}}...{{branch}}"),
+		FileURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/-/blob/{{sha}}/{{path}}"),
+		BlameURL:                       fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/-/blame/{{sha}}/{{path}}"),
+		NewIssueURL:                    fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/-/issues/new"),
+		PipelinesURL:                   fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/-/pipelines?ref={{branch}}"),
+		TagURL:                         fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/-/tags/{{tag}}"),
+		LineAnchor:                     "#L{{line}}",
+		LineRangeAnchor:                "#L{{startLine}}-{{endLine}}",
+		DraftPullRequestParam:          "&merge_request[title]=Draft%3A%20{{branch}}",
+		DraftTitlePrefix:               "Draft: ",
+		TitleParam:                     "&merge_request[title]={{title}}",
+		BodyParam:                      "&merge_request[description]={{body}}",
+		TargetProjectIDParam:           "&merge_request[target_project_id]={{targetProject}}",
+		ProjectIDCmd:                   "glab api --hostname {{host}} projects/{{projectPath}}",
+		CreatePullRequestCmd:           "glab mr create --yes --repo https://{{host}}/{{owner}}/{{repository}} --source-branch {{branch}}",
+		CreatePullRequestTargetFlag:    "--target-branch",
+		CreatePullRequestBodyFlag:      "--description",
+		ListPullRequestsCmd:            "glab mr list --repo https://{{host}}/{{owner}}/{{repository}} --output json",
+		parsePullRequests:              parseGitLabMergeRequests,
+	}
+}
+
+// gitea compares a lone head branch against the repo's default branch
+func newGiteaService(repositoryDomain string, siteDomain string) *Service {
+	return &Service{
+		Name:                           repositoryDomain,
+		Host:                           siteDomain,
+		PullRequestURL:                 fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/compare/{{branch}}"),
+		PullRequestURLIntoTargetBranch: fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/compare/{{targetBranch}}...{{branch}}"),
+		RepoURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}"),
+		CommitURL:                      fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/commit/{{sha}}"),
+		PullRequestNumberURL:           fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/pulls/{{number}}"),
+		CompareURL:                     fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/compare/{{targetBranch}}...{{branch}}"),
+		FileURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/src/commit/{{sha}}/{{path}}"),
+		BlameURL:                       fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/blame/commit/{{sha}}/{{path}}"),
+		NewIssueURL:                    fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/issues/new"),
+		TagURL:                         fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/releases/tag/{{tag}}"),
+		LineAnchor:                     "#L{{line}}",
+		LineRangeAnchor:                "#L{{startLine}}-L{{endLine}}",
+	}
+}
+
+// gogs 404s on a lone head branch, so it's always given a base to compare to
+func newGogsService(repositoryDomain string, siteDomain string) *Service {
+	return &Service{
+		Name:                           repositoryDomain,
+		Host:                           siteDomain,
+		PullRequestURL:                 fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/pulls"),
+		PullRequestURLIntoTargetBranch: fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/compare/{{targetBranch}}...{{branch}}"),
+		RepoURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}"),
+		CommitURL:                      fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/commit/{{sha}}"),
+		PullRequestNumberURL:           fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/pulls/{{number}}"),
+		CompareURL:                     fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/compare/{{targetBranch}}...{{branch}}"),
+		FileURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/src/{{sha}}/{{path}}"),
+		LineAnchor:                     "#L{{line}}",
+		LineRangeAnchor:                "#L{{startLine}}-L{{endLine}}",
+		RequiresTargetBranch:           true,
+	}
+}
+
+// sourcehut has no pull requests, so we open its web flow for emailing patches
+func newSourcehutService(repositoryDomain string, siteDomain string) *Service {
+	return &Service{
+		Name:                           repositoryDomain,
+		Host:                           siteDomain,
+		PullRequestURL:                 fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/send-email"),
+		PullRequestURLIntoTargetBranch: fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/send-email"),
+		RepoURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}"),
+		CommitURL:                      fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/commit/{{sha}}"),
+		FileURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/tree/{{sha}}/item/{{path}}"),
+		TagURL:                         fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{repository}}/refs/{{tag}}"),
+		LineAnchor:                     "#L{{line}}",
+		LineRangeAnchor:                "#L{{startLine}}-{{endLine}}",
+	}
+}
+
+// codecommit repos belong to a region rather than an owner, so we
+// treat the region as the owner
+func newCodeCommitService(repositoryDomain string, siteDomain string) *Service {
+	service := &Service{
+		Name:                           repositoryDomain,
+		Host:                           siteDomain,
+		PullRequestURL:                 fmt.Sprintf("https://%s%s", siteDomain, "/codesuite/codecommit/repositories/{{repository}}/pull-requests/new?region={{owner}}"),
+		PullRequestURLIntoTargetBranch: fmt.Sprintf("https://%s%s", siteDomain, "/codesuite/codecommit/repositories/{{repository}}/pull-requests/new/refs/heads/{{targetBranch}}/.../refs/heads/{{branch}}?region={{owner}}"),
+		RepoURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/codesuite/codecommit/repositories/{{repository}}/browse?region={{owner}}"),
+		CommitURL:                      fmt.Sprintf("https://%s%s", siteDomain, "/codesuite/codecommit/repositories/{{repository}}/commit/{{sha}}?region={{owner}}"),
+		PullRequestNumberURL:           fmt.Sprintf("https://%s%s", siteDomain, "/codesuite/codecommit/repositories/{{repository}}/pull-requests/{{number}}/details?region={{owner}}"),
+		CompareURL:                     fmt.Sprintf("https://%s%s", siteDomain, "/codesuite/codecommit/repositories/{{repository}}/compare/{{targetBranch}}/.../{{branch}}?region={{owner}}"),
+		FileURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/codesuite/codecommit/repositories/{{repository}}/browse/{{sha}}/--/{{path}}?region={{owner}}"),
+	}
+
+	// all of AWS lives under amazonaws.com, of which only the regional
+	// git-codecommit hosts are CodeCommit
+	if repositoryDomain == codeCommitDomain {
+		service.matchesHost = isCodeCommitHost
+	}
+
+	return service
+}
+
+func isCodeCommitHost(host string) bool {
+	return strings.HasPrefix(host, codeCommitHostPrefix) && strings.HasSuffix(host, "."+codeCommitDomain)
+}
+
+// phabricator has no pull request form to link to, so we only know its host
+func newPhabricatorService(repositoryDomain string, siteDomain string) *Service {
+	return &Service{
+		Name:        repositoryDomain,
+		Host:        siteDomain,
+		UsesArcDiff: true,
+	}
+}
+
+func newAzureDevOpsService(repositoryDomain string, siteDomain string) *Service {
+	return &Service{
+		Name:                           repositoryDomain,
+		Host:                           siteDomain,
+		PullRequestURL:                 fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{project}}/_git/{{repository}}/pullrequestcreate?sourceRef={{branch}}"),
+		PullRequestURLIntoTargetBranch: fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{project}}/_git/{{repository}}/pullrequestcreate?sourceRef={{branch}}&targetRef={{targetBranch}}"),
+		RepoURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{project}}/_git/{{repository}}"),
+		CommitURL:                      fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{project}}/_git/{{repository}}/commit/{{sha}}"),
+		PullRequestNumberURL:           fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{project}}/_git/{{repository}}/pullrequest/{{number}}"),
+		CompareURL:                     fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{project}}/_git/{{repository}}/branchCompare?baseVersion=GB{{targetBranch}}&targetVersion=GB{{branch}}"),
+		FileURL:                        fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{project}}/_git/{{repository}}?path=/{{path}}&version=GC{{sha}}"),
+		TagURL:                         fmt.Sprintf("https://%s%s", siteDomain, "/{{owner}}/{{project}}/_git/{{repository}}?version=GT{{tag}}"),
+		LineAnchor:                     "&line={{line}}",
+		LineRangeAnchor:                "&line={{startLine}}&lineEnd={{endLine}}",
+	}
+}
+
 func getServices(config config.AppConfigurer) []*Service {
-	services := []*Service{
-		NewService("github", "github.com", "github.com"),
-		NewService("bitbucket", "bitbucket.org", "bitbucket.org"),
-		NewService("gitlab", "gitlab.com", "gitlab.com"),
+	services := getServicesFromConfig(config.GetUserConfig().Services)
+
+	for repoDomain, urlTemplate := range config.GetUserConfig().PullRequestURLTemplates {
+		repoDomain = bracketIPv6(repoDomain)
+		service := findServiceByName(services, repoDomain)
+		if service == nil {
+			service = &Service{Name: repoDomain, Host: repoDomain}
+			services = append([]*Service{service}, services...)
+		}
+
+		service.PullRequestURLTemplate = urlTemplate
+	}
+
+	for repoDomain, base := range config.GetUserConfig().PR.DefaultBaseByDomain {
+		// there's nothing to base pull requests on for a domain without a service
+		if service := findServiceByName(services, bracketIPv6(repoDomain)); service != nil {
+			service.DefaultBase = base
+		}
 	}
 
-	configServices := config.GetUserConfig().Services
+	return services
+}
+
+// getServicesFromConfig returns the services defined by the given Services
+// config followed by the built-in ones
+func getServicesFromConfig(configServices map[string]string) []*Service {
+	// configured services come before the built-in ones so that they take
+	// precedence, e.g. for a host like github.com.mycorp.net
+	services := []*Service{}
+
+	// sorted so that the order in which we match services is stable
+	repoDomains := make([]string, 0, len(configServices))
+	for repoDomain := range configServices {
+		repoDomains = append(repoDomains, repoDomain)
+	}
+	sort.Strings(repoDomains)
 
-	for repoDomain, typeAndDomain := range configServices {
-		splitData := strings.Split(typeAndDomain, ":")
-		if len(splitData) != 2 {
-			// TODO log this misconfiguration
+	for _, repoDomain := range repoDomains {
+		// misconfigured entries are reported when the config is loaded
+		serviceType, siteDomain, ok := config.SplitServiceEntry(configServices[repoDomain])
+		if !ok {
 			continue
 		}
 
-		service := NewService(splitData[0], repoDomain, splitData[1])
+		service := NewService(serviceType, repoDomain, siteDomain)
 		if service == nil {
-			// TODO log this unsupported service
 			continue
 		}
 
 		services = append(services, service)
 	}
 
+	services = append(services,
+		NewService("github", "github.com", "github.com"),
+		NewService("bitbucket", "bitbucket.org", "bitbucket.org"),
+		NewService("gitlab", "gitlab.com", "gitlab.com"),
+		NewService("azuredevops", azureDevOpsDomain, azureDevOpsDomain),
+		NewService("codecommit", codeCommitDomain, codeCommitConsole),
+		NewService("gitea", "codeberg.org", "codeberg.org"),
+		NewService("sourcehut", "git.sr.ht", "git.sr.ht"),
+	)
+
 	return services
 }
 
+// getService returns the provider (e.g. 'github') and web host of the service
+// the remote url belongs to, looking at the given Services config before the
+// built-in services
+func getService(remoteURL string, configServices map[string]string) (string, string, error) {
+	service := matchService(getServicesFromConfig(configServices), remoteURL)
+	if service == nil {
+		return "", "", newErrUnsupportedGitService(remoteURL, "no git service found for remote url "+stripCredentials(remoteURL))
+	}
+
+	return service.Type, service.Host, nil
+}
+
+// matchService returns the service the remote url belongs to, or nil if there
+// is none. A service matches if its git domain is the remote's host or a parent
+// domain of it, so github.com covers ssh.github.com but not notgithub.com, and
+// the url's path is never looked at. A service whose git domain is exactly the
+// host wins, and failing that the longest git domain as the most specific, so
+// corp.net loses out to git.corp.net for git@git.corp.net:owner/repo. Ties go
+// to the service that comes first, which puts configured services ahead of
+// built-in ones. Hosts are case-insensitive, so git@GitHub.com:owner/repo is on
+// GitHub too. IP addresses only match exactly, given 10.0.0.5 has nothing to do
+// with 10.0.0.50
+func matchService(services []*Service, remoteURL string) *Service {
+	if unwrappedURL, err := unwrapRemoteURL(remoteURL); err == nil {
+		remoteURL = unwrappedURL
+	}
+	// so that a token embedded in the url can't be mistaken for a host
+	host, _ := splitRemoteURL(stripCredentials(remoteURL))
+
+	var match *Service
+	for _, service := range services {
+		if service.matchesHost != nil && !service.matchesHost(host) {
+			continue
+		}
+
+		name := strings.ToLower(service.Name)
+		if name == host {
+			return service
+		}
+
+		if isIPAddress(name) {
+			continue
+		}
+
+		if strings.HasSuffix(host, "."+name) && (match == nil || len(service.Name) > len(match.Name)) {
+			match = service
+		}
+	}
+
+	return match
+}
+
+func findServiceByName(services []*Service, name string) *Service {
+	for _, service := range services {
+		if service.Name == name {
+			return service
+		}
+	}
+
+	return nil
+}
+
 // NewPullRequest creates new instance of PullRequest
 func NewPullRequest(gitCommand *GitCommand) *PullRequest {
 	return &PullRequest{
 		GitServices: getServices(gitCommand.Config),
 		GitCommand:  gitCommand,
+		sleep:       time.Sleep,
+		httpClient:  http.DefaultClient,
 	}
 }
 
-// Create opens link to new pull request in browser
-func (pr *PullRequest) Create(branch *models.Branch) error {
-	pullRequestURL, err := pr.getPullRequestURL(branch)
+// Create opens link to new pull request in browser, returning the link
+func (pr *PullRequest) Create(branch *models.Branch) (string, error) {
+	return pr.CreateWithTarget(branch, "")
+}
+
+// CreateFromRef is like Create but takes a branch name or a commit sha, which
+// is handy when HEAD is detached. For a sha, we open a pull request for the
+// branch containing that commit
+func (pr *PullRequest) CreateFromRef(ref string) (string, error) {
+	branch, err := pr.resolveBranch(ref)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return pr.GitCommand.OSCommand.OpenLink(pullRequestURL)
+	return pr.Create(branch)
 }
 
-// CopyURL copies the pull request URL to the clipboard
-func (pr *PullRequest) CopyURL(branch *models.Branch) error {
-	pullRequestURL, err := pr.getPullRequestURL(branch)
+// CreateForCurrentBranch is like Create but for the checked out branch. It
+// fails if HEAD is detached
+func (pr *PullRequest) CreateForCurrentBranch() (string, error) {
+	output, err := pr.GitCommand.OSCommand.RunCommandWithOutput("git rev-parse --abbrev-ref HEAD")
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	branchName := strings.TrimSpace(output)
+	if branchName == "HEAD" {
+		return "", errors.New(pr.GitCommand.Tr.DetachedHeadPullRequest)
+	}
+
+	return pr.Create(&models.Branch{Name: branchName})
+}
+
+// resolveBranch returns the local branch with the given name, or failing that
+// the one local branch which contains the given commit
+func (pr *PullRequest) resolveBranch(ref string) (*models.Branch, error) {
+	osCommand := pr.GitCommand.OSCommand
+	if _, err := osCommand.RunCommandWithOutput("git show-ref --verify -- refs/heads/%s", ref); err == nil {
+		return &models.Branch{Name: ref}, nil
+	}
+
+	output, err := osCommand.RunCommandWithOutput("git branch --contains %s --format=%%(refname:short)", ref)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf(pr.GitCommand.Tr.NoBranchContainsRef, ref))
+	}
+
+	branchNames := []string{}
+	for _, line := range utils.SplitLines(output) {
+		// skips a detached HEAD, which is listed like '(HEAD detached at 123abcd)'
+		if line == "" || strings.HasPrefix(line, "(") {
+			continue
+		}
+		branchNames = append(branchNames, line)
+	}
+
+	switch len(branchNames) {
+	case 0:
+		return nil, errors.New(fmt.Sprintf(pr.GitCommand.Tr.NoBranchContainsRef, ref))
+	case 1:
+		return &models.Branch{Name: branchNames[0]}, nil
+	default:
+		return nil, errors.New(fmt.Sprintf(pr.GitCommand.Tr.MultipleBranchesContainRef, ref, strings.Join(branchNames, ", ")))
+	}
+}
+
+// CreateAsync is like Create but doesn't wait for the browser to be launched,
+// which can take a while. onDone is called from another goroutine once we're
+// finished, with the link we opened or any error we've hit along the way
+func (pr *PullRequest) CreateAsync(branch *models.Branch, onDone func(string, error)) {
+	pr.CreateWithOptionsAsync(branch, PullRequestOptions{}, onDone)
+}
+
+// CreateWithOptionsAsync is like CreateAsync but with the given options
+func (pr *PullRequest) CreateWithOptionsAsync(branch *models.Branch, opts PullRequestOptions, onDone func(string, error)) {
+	go utils.Safe(func() {
+		onDone(pr.CreateWithOptions(branch, opts))
+	})
+}
+
+// CreateWithTarget opens link to new pull request in browser, targeting the
+// given branch. If target is empty the branch's upstream is used if it tracks a
+// differently-named branch, otherwise the service's default branch
+func (pr *PullRequest) CreateWithTarget(branch *models.Branch, target string) (string, error) {
+	return pr.CreateWithOptions(branch, PullRequestOptions{Target: target})
+}
+
+// CreateStack opens pull requests for a stack of branches, bottom first, with
+// each branch targeting the one below it. The bottom branch targets what
+// Create's would, usually the default branch. We stop at the first pull request
+// we fail to open, returning the links of those we opened. A failing
+// PR.PostCreateCommand doesn't stop us, and we return its last error at the end
+func (pr *PullRequest) CreateStack(branches []*models.Branch) ([]string, error) {
+	urls := []string{}
+	var postCreateErr error
+	for i, branch := range branches {
+		target := ""
+		if i > 0 {
+			target = branches[i-1].Name
+		}
+
+		url, err := pr.CreateWithTarget(branch, target)
+		if _, ok := err.(*ErrPostCreateCommand); ok {
+			postCreateErr = err
+		} else if err != nil {
+			return urls, err
+		}
+		urls = append(urls, url)
 	}
 
-	return pr.GitCommand.OSCommand.CopyToClipboard(pullRequestURL)
+	return urls, postCreateErr
 }
 
-func (pr *PullRequest) getPullRequestURL(branch *models.Branch) (string, error) {
-	branchExistsOnRemote := pr.GitCommand.CheckRemoteBranchExists(branch)
+// CreateDraft is like CreateWithTarget but opens the pull request as a draft.
+// It fails for services which don't support draft pull requests
+func (pr *PullRequest) CreateDraft(branch *models.Branch, target string) (string, error) {
+	return pr.CreateWithOptions(branch, PullRequestOptions{Target: target, Draft: true})
+}
 
-	if !branchExistsOnRemote {
-		return "", errors.New(pr.GitCommand.Tr.NoBranchOnRemote)
+// CreateWithOptions opens link to new pull request in browser, set up according
+// to the given options. If PR.OpenMode is 'clipboard' the link is copied to the
+// clipboard instead. See PullRequestOptions.Reviewers for when we create the
+// pull request with the service's CLI. The link is returned so that it can be
+// shown to the user. See ErrDefaultBranchPullRequest and ErrPostCreateCommand
+// for the warnings we return
+func (pr *PullRequest) CreateWithOptions(branch *models.Branch, opts PullRequestOptions) (string, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getRemoteName(branch))
+	if err != nil {
+		return "", err
 	}
 
-	repoURL := pr.GitCommand.GetRemoteURL()
-	var gitService *Service
+	opts.repoConfig = pr.loadRepoConfig()
 
-	for _, service := range pr.GitServices {
-		if strings.Contains(repoURL, service.Name) {
-			gitService = service
-			break
+	if gitService.UsesArcDiff {
+		revisionURL, err := pr.createWithArc(gitService, branch, opts)
+		if err != nil || revisionURL == "" {
+			return revisionURL, err
 		}
+		return revisionURL, pr.runPostCreateCommand(branch, revisionURL)
 	}
 
-	if gitService == nil {
-		return "", errors.New(pr.GitCommand.Tr.UnsupportedGitService)
+	// building the url first means we report a bad remote without shelling out
+	pullRequestURL, err := pr.getPullRequestURL(branch, opts)
+	if err != nil {
+		return "", err
 	}
 
-	repoInfo := getRepoInfoFromURL(repoURL)
-	pullRequestURL := fmt.Sprintf(
-		gitService.PullRequestURL, repoInfo.Owner, repoInfo.Repository, branch.Name,
-	)
+	if err := pr.checkNotIntoDefaultBranch(gitService, repoInfo, branch, opts); err != nil {
+		return "", err
+	}
 
-	return pullRequestURL, nil
+	if err := pr.checkBranchExistsOnRemote(branch); err != nil {
+		return "", err
+	}
+
+	createdURL, created, err := pr.createWithAPI(gitService, repoInfo, branch, opts)
+	if err != nil {
+		return "", err
+	}
+
+	useCLI := pr.GitCommand.Config.GetUserConfig().PR.UseCLIWhenAvailable
+	if !created && (useCLI || len(opts.Reviewers) > 0 || len(opts.Labels) > 0) {
+		createdURL, created, err = pr.createWithCLI(branch, opts)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if created {
+		// the pull request exists now, so we open it rather than the form
+		pullRequestURL = createdURL
+	}
+
+	if err := pr.openOrCopyLink(branch, pullRequestURL); err != nil {
+		return "", err
+	}
+
+	return pullRequestURL, pr.runPostCreateCommand(branch, pullRequestURL)
 }
 
-func getRepoInfoFromURL(url string) *RepoInformation {
-	isHTTP := strings.HasPrefix(url, "http")
+// runPostCreateCommand runs PR.PostCreateCommand, if set, for the pull request
+// of the branch at the given link. It runs in the repo root
+func (pr *PullRequest) runPostCreateCommand(branch *models.Branch, link string) error {
+	commandTemplate := pr.GitCommand.Config.GetUserConfig().PR.PostCreateCommand
+	if commandTemplate == "" {
+		return nil
+	}
 
-	if isHTTP {
-		splits := strings.Split(url, "/")
-		owner := strings.Join(splits[3:len(splits)-1], "/")
-		repo := strings.TrimSuffix(splits[len(splits)-1], ".git")
+	osCommand := pr.GitCommand.OSCommand
+	command := utils.ResolvePlaceholderString(commandTemplate, map[string]string{
+		"link":   osCommand.Quote(link),
+		"branch": osCommand.Quote(branch.Name),
+	})
 
-		return &RepoInformation{
-			Owner:      owner,
-			Repository: repo,
+	options := oscommands.RunCommandOptions{Dir: pr.getCommandDir(), Timeout: pr.getCommandTimeout()}
+	if err := osCommand.RunCommandWithOptions(command, options); err != nil {
+		return &ErrPostCreateCommand{
+			Command: command,
+			message: fmt.Sprintf(pr.GitCommand.Tr.PostCreateCommandFailed, err.Error()),
 		}
 	}
 
-	tmpSplit := strings.Split(url, ":")
-	splits := strings.Split(tmpSplit[1], "/")
-	owner := strings.Join(splits[0:len(splits)-1], "/")
-	repo := strings.TrimSuffix(splits[len(splits)-1], ".git")
+	return nil
+}
 
-	return &RepoInformation{
-		Owner:      owner,
-		Repository: repo,
+// checkNotIntoDefaultBranch returns an ErrDefaultBranchPullRequest if the
+// branch is the repo's default branch and the pull request would merge it into
+// itself. That's not so when it goes into another project, as it does from a
+// fork, or into another branch
+func (pr *PullRequest) checkNotIntoDefaultBranch(gitService *Service, repoInfo *RepoInformation, branch *models.Branch, opts PullRequestOptions) error {
+	if opts.AllowDefaultBranch || opts.TargetProject != "" {
+		return nil
+	}
+
+	if _, head := pr.getForkHead(gitService, repoInfo, branch); head != branch.Name {
+		return nil
+	}
+
+	if target := pr.getTargetBranch(gitService, branch, opts); target != "" && target != branch.Name {
+		return nil
+	}
+
+	if branch.Name != pr.GitCommand.GetDefaultBranch() {
+		return nil
+	}
+
+	return &ErrDefaultBranchPullRequest{
+		Branch:  branch.Name,
+		message: fmt.Sprintf(pr.GitCommand.Tr.DefaultBranchPullRequest, branch.Name),
+	}
+}
+
+// openOrCopyLink opens the given link to a pull request of the branch, or
+// copies it to the clipboard if that's what PR.OpenMode asks for
+func (pr *PullRequest) openOrCopyLink(branch *models.Branch, link string) error {
+	if pr.GitCommand.Config.GetUserConfig().PR.OpenMode == config.PROpenModeClipboard {
+		return pr.copyLink(branch, link)
+	}
+
+	return pr.openLinkWithRetries(link, pr.getOpenLinkTemplateValues(branch))
+}
+
+// getOpenLinkTemplateValues returns the placeholders the open link command can
+// use besides {{link}} when opening a link to a pull request of the branch, so
+// that a script can e.g. reuse a browser tab it already has open for the repo
+func (pr *PullRequest) getOpenLinkTemplateValues(branch *models.Branch) map[string]string {
+	templateValues := map[string]string{"branch": branch.Name}
+
+	gitService, repoInfo, err := pr.getRemoteService(pr.getRemoteName(branch))
+	if err != nil {
+		// we'd have failed to build the link in the first place, so this won't
+		// happen, but the link can still be opened without these
+		pr.GitCommand.Log.Error(err)
+		return templateValues
+	}
+
+	templateValues["host"] = gitService.Host
+	templateValues["owner"] = repoInfo.Owner
+	templateValues["repo"] = repoInfo.Repository
+
+	return templateValues
+}
+
+// copyLink copies the given link to a pull request of the branch to the
+// clipboard, as a markdown link if PR.ClipboardFormat asks for it
+func (pr *PullRequest) copyLink(branch *models.Branch, link string) error {
+	if pr.GitCommand.Config.GetUserConfig().PR.ClipboardFormat == config.PRClipboardFormatMarkdown {
+		_, repoInfo, err := pr.getRemoteService(pr.getRemoteName(branch))
+		if err != nil {
+			return err
+		}
+
+		link = markdownLink(repoInfo.Owner+"/"+repoInfo.Repository+"#"+branch.Name, link)
+	}
+
+	return pr.GitCommand.OSCommand.CopyToClipboard(link)
+}
+
+// markdownLink returns a markdown link to url with the given text, escaping any
+// brackets in the text so they don't end it early
+func markdownLink(text string, url string) string {
+	text = strings.NewReplacer(`[`, `\[`, `]`, `\]`).Replace(text)
+	return "[" + text + "](" + url + ")"
+}
+
+// matches the line in which arc diff reports the revision it created or updated
+var arcRevisionURIRegexp = regexp.MustCompile(`(?m)^Revision URI: (\S+)`)
+
+// createWithArc creates a Phabricator revision of the branch with arc diff,
+// then opens it. arc has no terminal to open an editor in, so we have it use
+// the commit messages as they are. The link is empty if arc didn't report one
+func (pr *PullRequest) createWithArc(gitService *Service, branch *models.Branch, opts PullRequestOptions) (string, error) {
+	osCommand := pr.GitCommand.OSCommand
+	if _, err := osCommand.LookPath("arc"); err != nil {
+		return "", errors.New(pr.GitCommand.Tr.ArcNotFound)
+	}
+
+	command := "arc diff --verbatim --head " + osCommand.Quote(branch.Name)
+	if target := pr.getTargetBranch(gitService, branch, opts); target != "" {
+		command += " " + osCommand.Quote(target)
+	}
+
+	output, err := osCommand.RunCommandWithOutput(command)
+	if err != nil {
+		return "", err
+	}
+
+	match := arcRevisionURIRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return "", nil
+	}
+
+	if err := pr.openOrCopyLink(branch, match[1]); err != nil {
+		return "", err
+	}
+
+	return match[1], nil
+}
+
+// bitbucketPullRequestRequest is the body with which we create a pull request
+// with Bitbucket Cloud's REST API. Without a destination, the pull request goes
+// into the repo's main branch
+type bitbucketPullRequestRequest struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description,omitempty"`
+	Source      bitbucketBranchRef  `json:"source"`
+	Destination *bitbucketBranchRef `json:"destination,omitempty"`
+}
+
+type bitbucketBranchRef struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+}
+
+func newBitbucketBranchRef(name string) *bitbucketBranchRef {
+	ref := &bitbucketBranchRef{}
+	ref.Branch.Name = name
+	return ref
+}
+
+// bitbucketPullRequestResponse is what we read from Bitbucket Cloud's response
+// to creating a pull request: the link to it, or the error we hit
+type bitbucketPullRequestResponse struct {
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// createWithAPI creates the pull request with the service's REST API, returning
+// the link to it. It returns false if the service has no API we know of or the
+// user hasn't given us credentials for it, in which case the caller goes on to
+// the CLI or the web form. Only Bitbucket Cloud has one, which we authenticate
+// with an app password
+func (pr *PullRequest) createWithAPI(gitService *Service, repoInfo *RepoInformation, branch *models.Branch, opts PullRequestOptions) (string, bool, error) {
+	credentials := pr.GitCommand.Config.GetUserConfig().PR.BitbucketAPI
+	if gitService.CreatePullRequestAPIURL == "" || credentials.Username == "" || credentials.AppPassword == "" {
+		return "", false, nil
+	}
+
+	// bitbucket won't create a pull request without a title
+	title := opts.Title
+	if title == "" {
+		title = branch.Name
+	}
+
+	request := bitbucketPullRequestRequest{
+		Title:       title,
+		Description: opts.Body,
+		Source:      *newBitbucketBranchRef(branch.Name),
+	}
+	if target := pr.getTargetBranch(gitService, branch, opts); target != "" {
+		request.Destination = newBitbucketBranchRef(target)
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", true, err
+	}
+
+	ctx := context.Background()
+	if timeout := pr.getCommandTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	apiURL := resolveRepoPlaceholders(gitService.CreatePullRequestAPIURL, repoInfo, nil)
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return "", true, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.SetBasicAuth(credentials.Username, credentials.AppPassword)
+
+	pr.GitCommand.Log.WithField("url", apiURL).Info("creating pull request with the bitbucket api")
+	httpResponse, err := pr.httpClient.Do(httpRequest)
+	if err != nil {
+		return "", true, err
+	}
+	defer httpResponse.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return "", true, err
+	}
+
+	response := bitbucketPullRequestResponse{}
+	// an error page may not be json, in which case we go by the status alone
+	jsonErr := json.Unmarshal(responseBody, &response)
+
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode >= 300 {
+		message := httpResponse.Status
+		if response.Error.Message != "" {
+			message = response.Error.Message
+		}
+		return "", true, errors.New(fmt.Sprintf(pr.GitCommand.Tr.CreatePullRequestAPIFailed, message))
+	}
+
+	if jsonErr != nil {
+		return "", true, jsonErr
+	}
+
+	if response.Links.HTML.Href == "" {
+		return "", true, errors.New("unexpected response from the bitbucket api: " + string(responseBody))
+	}
+
+	return response.Links.HTML.Href, true, nil
+}
+
+// createWithCLI creates the pull request via the service's CLI, returning the
+// link to it which the CLI prints. It returns false if the service has no CLI
+// we know of or it isn't installed, in which case the caller falls back to the
+// web form
+func (pr *PullRequest) createWithCLI(branch *models.Branch, opts PullRequestOptions) (string, bool, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getRemoteName(branch))
+	if err != nil {
+		return "", false, err
+	}
+
+	if gitService.CreatePullRequestCmd == "" {
+		return "", false, nil
+	}
+
+	cli := strings.Fields(gitService.CreatePullRequestCmd)[0]
+	if _, err := pr.GitCommand.OSCommand.LookPath(cli); err != nil {
+		return "", false, nil
+	}
+
+	target := pr.getTargetBranch(gitService, branch, opts)
+	repoInfo, head := pr.getForkHead(gitService, repoInfo, branch)
+
+	// we pass each argument on as is rather than quoting them into a command
+	// string, as a title or body can contain anything
+	args := []string{}
+	for _, field := range strings.Fields(gitService.CreatePullRequestCmd) {
+		args = append(args, resolveRepoPlaceholders(field, repoInfo, map[string]string{
+			"host":   gitService.Host,
+			"branch": head,
+		}))
+	}
+	targetFlag, bodyFlag := "--base", "--body"
+	if gitService.CreatePullRequestTargetFlag != "" {
+		targetFlag = gitService.CreatePullRequestTargetFlag
+	}
+	if gitService.CreatePullRequestBodyFlag != "" {
+		bodyFlag = gitService.CreatePullRequestBodyFlag
+	}
+
+	if target != "" {
+		args = append(args, targetFlag, target)
+	}
+	if opts.Title != "" || opts.Body != "" {
+		args = append(args, "--title", opts.Title, bodyFlag, opts.Body)
+	} else {
+		args = append(args, "--fill")
+	}
+	if opts.Draft {
+		args = append(args, "--draft")
+	}
+	if len(opts.Reviewers) > 0 {
+		args = append(args, "--reviewer", strings.Join(opts.Reviewers, ","))
+	}
+	if len(opts.Labels) > 0 {
+		args = append(args, "--label", strings.Join(opts.Labels, ","))
+	}
+
+	output, err := pr.GitCommand.OSCommand.RunArgsWithOutputWithOptions(args, pr.getCLIOptions())
+	if err != nil {
+		return "", true, err
+	}
+
+	// the link comes last, after any warnings
+	lines := utils.SplitLines(strings.TrimSpace(output))
+	if len(lines) == 0 {
+		return "", true, errors.New("unexpected output from " + cli + ": " + output)
+	}
+
+	return strings.TrimSpace(lines[len(lines)-1]), true, nil
+}
+
+// openLinkWithRetries opens the given link, trying again if the command fails
+// as configured by PR.OpenAttempts and PR.OpenRetryDelay. The delay doubles
+// after each failed attempt. PR.OpenInPrivateWindow has it opened in a private
+// window, and templateValues are passed on as OpenLinkOptions.TemplateValues
+func (pr *PullRequest) openLinkWithRetries(link string, templateValues map[string]string) error {
+	prConfig := pr.GitCommand.Config.GetUserConfig().PR
+	delay := time.Duration(prConfig.OpenRetryDelay) * time.Millisecond
+
+	options := oscommands.OpenLinkOptions{
+		PrivateWindow:  prConfig.OpenInPrivateWindow,
+		Dir:            pr.getOpenLinkDir(),
+		TemplateValues: templateValues,
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := pr.GitCommand.OSCommand.OpenLinkWithOptions(link, options)
+		if err == nil || attempt >= prConfig.OpenAttempts {
+			return err
+		}
+
+		pr.sleep(delay)
+		delay *= 2
+	}
+}
+
+// getOpenLinkDir returns the directory to run the open link command in, which
+// is PR.OpenWorkingDir taken from the repo root, or the repo root itself if
+// that's not set
+func (pr *PullRequest) getOpenLinkDir() string {
+	dir := pr.GitCommand.Config.GetUserConfig().PR.OpenWorkingDir
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+
+	root := pr.getCommandDir()
+	if root == "" {
+		return ""
+	}
+
+	return filepath.Join(root, dir)
+}
+
+// getCommandDir returns the repo root, for the commands we run for pull
+// requests to run in even if lazygit was started from a subdirectory. Outside of
+// a repo it returns an empty string, so they run in our directory as they
+// otherwise would
+func (pr *PullRequest) getCommandDir() string {
+	root, _ := pr.GitCommand.RepoRootDir()
+	return root
+}
+
+// CopyURL copies the pull request URL to the clipboard, formatted as
+// PR.ClipboardFormat asks
+func (pr *PullRequest) CopyURL(branch *models.Branch) error {
+	if err := pr.checkBranchExistsOnRemote(branch); err != nil {
+		return err
+	}
+
+	pullRequestURL, err := pr.URL(branch)
+	if err != nil {
+		return err
+	}
+
+	return pr.copyLink(branch, pullRequestURL)
+}
+
+// URL returns the link to a new pull request for the given branch without
+// opening it. Unlike Create, it doesn't check that the branch exists on the
+// remote, so it never has to shell out
+func (pr *PullRequest) URL(branch *models.Branch) (string, error) {
+	return pr.getPullRequestURL(branch, PullRequestOptions{})
+}
+
+// TargetBranch returns the branch a pull request of the given branch with the
+// given options would merge into, falling back to the repo's default branch
+// where the URL leaves that to the service. It returns an empty string if we
+// can't work that out
+func (pr *PullRequest) TargetBranch(branch *models.Branch, opts PullRequestOptions) (string, error) {
+	gitService, _, err := pr.getRemoteService(pr.getRemoteName(branch))
+	if err != nil {
+		return "", err
+	}
+
+	if target := pr.getTargetBranch(gitService, branch, opts); target != "" {
+		return target, nil
+	}
+
+	return pr.GitCommand.GetDefaultBranch(), nil
+}
+
+// ListPullRequests returns the open pull requests of the repo using the git
+// service's CLI, i.e. gh for GitHub and glab for GitLab
+func (pr *PullRequest) ListPullRequests() ([]*models.PullRequest, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getDefaultRemoteName())
+	if err != nil {
+		return nil, err
+	}
+
+	if gitService.ListPullRequestsCmd == "" {
+		return nil, errors.New(pr.GitCommand.Tr.ListPullRequestsUnsupported)
+	}
+
+	cli := strings.Fields(gitService.ListPullRequestsCmd)[0]
+	if _, err := pr.GitCommand.OSCommand.LookPath(cli); err != nil {
+		return nil, errors.New(fmt.Sprintf(pr.GitCommand.Tr.PullRequestCLINotFound, cli))
+	}
+
+	command := resolveRepoPlaceholders(gitService.ListPullRequestsCmd, repoInfo, map[string]string{
+		"host": gitService.Host,
+	})
+
+	output, err := pr.GitCommand.OSCommand.RunCommandWithOutputWithOptions(command, pr.getCLIOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	return gitService.parsePullRequests(output)
+}
+
+// getCLIOptions returns the options to run a service's CLI with. It runs in
+// the repo root, even if lazygit was started from a subdirectory, and inherits
+// our environment, so it sees the same repo and git identity that we do
+func (pr *PullRequest) getCLIOptions() oscommands.RunCommandOptions {
+	envVars := append(pr.getProxyEnvVars(), pr.GitCommand.OSCommand.GitPathEnvVars()...)
+
+	return oscommands.RunCommandOptions{EnvVars: envVars, Dir: pr.getCommandDir(), Timeout: pr.getCommandTimeout()}
+}
+
+// getCommandTimeout returns how long we give the services' CLIs and APIs and
+// the post-create command to finish, as set by PR.CommandTimeout. Zero means no
+// limit. The open link command isn't timed out, as a launcher like xdg-open can
+// keep running for as long as the browser it started
+func (pr *PullRequest) getCommandTimeout() time.Duration {
+	return time.Duration(pr.GitCommand.Config.GetUserConfig().PR.CommandTimeout) * time.Millisecond
+}
+
+// getProxyEnvVars returns the proxy settings to run a service's CLI with. If
+// the environment doesn't set a proxy, we fall back to git's http.proxy so that
+// the CLI goes through the same proxy as git does
+func (pr *PullRequest) getProxyEnvVars() []string {
+	osCommand := pr.GitCommand.OSCommand
+	envVars := osCommand.ProxyEnvVars()
+
+	for _, name := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+		if osCommand.Getenv(name) != "" {
+			return envVars
+		}
+	}
+
+	if gitProxy := pr.GitCommand.GetConfigValue("http.proxy"); gitProxy != "" {
+		envVars = append(envVars, "HTTP_PROXY="+gitProxy, "HTTPS_PROXY="+gitProxy)
+	}
+
+	return envVars
+}
+
+// RepoURL returns the link to the repo's homepage on the remote's git service
+func (pr *PullRequest) RepoURL() (string, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getDefaultRemoteName())
+	if err != nil {
+		return "", err
+	}
+
+	if gitService.RepoURL == "" {
+		return "", errors.New(pr.GitCommand.Tr.UnsupportedGitService)
+	}
+
+	return resolveRepoPlaceholders(gitService.RepoURL, repoInfo, nil), nil
+}
+
+// OpenRepo opens the repo's homepage in browser
+func (pr *PullRequest) OpenRepo() error {
+	repoURL, err := pr.RepoURL()
+	if err != nil {
+		return err
+	}
+
+	return pr.GitCommand.OSCommand.OpenLink(repoURL)
+}
+
+// CommitURL returns the link to the given commit on the remote's git service
+func (pr *PullRequest) CommitURL(sha string) (string, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getDefaultRemoteName())
+	if err != nil {
+		return "", err
+	}
+
+	if gitService.CommitURL == "" {
+		return "", errors.New(pr.GitCommand.Tr.UnsupportedGitService)
+	}
+
+	return resolveRepoPlaceholders(gitService.CommitURL, repoInfo, map[string]string{
+		"sha": sha,
+	}), nil
+}
+
+// PullRequestNumberURL returns the link to the existing pull request with the
+// given number on the remote's git service
+func (pr *PullRequest) PullRequestNumberURL(number int) (string, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getDefaultRemoteName())
+	if err != nil {
+		return "", err
+	}
+
+	if gitService.PullRequestNumberURL == "" {
+		return "", errors.New(pr.GitCommand.Tr.UnsupportedGitService)
+	}
+
+	return resolveRepoPlaceholders(gitService.PullRequestNumberURL, repoInfo, map[string]string{
+		"number": strconv.Itoa(number),
+	}), nil
+}
+
+// OpenPullRequestNumber opens the existing pull request with the given number
+// in the browser
+func (pr *PullRequest) OpenPullRequestNumber(number int) error {
+	pullRequestURL, err := pr.PullRequestNumberURL(number)
+	if err != nil {
+		return err
+	}
+
+	return pr.GitCommand.OSCommand.OpenLink(pullRequestURL)
+}
+
+// CompareURL returns the link to the comparison of head against base on the
+// remote's git service
+func (pr *PullRequest) CompareURL(base string, head string) (string, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getDefaultRemoteName())
+	if err != nil {
+		return "", err
+	}
+
+	if gitService.CompareURL == "" {
+		return "", errors.New(pr.GitCommand.Tr.CompareUnsupported)
+	}
+
+	compareURL := encodeBranchPlaceholders(gitService.CompareURL, map[string]string{
+		"targetBranch": base,
+		"branch":       head,
+	})
+
+	return resolveRepoPlaceholders(compareURL, repoInfo, nil), nil
+}
+
+// CopyCommitURL copies the link to the given commit to the clipboard
+func (pr *PullRequest) CopyCommitURL(sha string) error {
+	commitURL, err := pr.CommitURL(sha)
+	if err != nil {
+		return err
+	}
+
+	return pr.GitCommand.OSCommand.CopyToClipboard(commitURL)
+}
+
+// FileURL returns the link to the given file at the given commit on the
+// remote's git service, highlighting the lines from startLine to endLine. If
+// endLine isn't after startLine, only startLine is highlighted, and if startLine
+// isn't positive no lines are
+func (pr *PullRequest) FileURL(sha string, path string, startLine int, endLine int) (string, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getDefaultRemoteName())
+	if err != nil {
+		return "", err
+	}
+
+	if gitService.FileURL == "" {
+		return "", errors.New(pr.GitCommand.Tr.UnsupportedGitService)
+	}
+
+	urlTemplate := gitService.FileURL
+	if startLine > 0 {
+		if endLine > startLine {
+			urlTemplate += gitService.LineRangeAnchor
+		} else {
+			urlTemplate += gitService.LineAnchor
+		}
+	}
+
+	return resolveRepoPlaceholders(urlTemplate, repoInfo, map[string]string{
+		"sha":       sha,
+		"path":      encodePathValue(strings.TrimPrefix(filepath.ToSlash(path), "/")),
+		"line":      strconv.Itoa(startLine),
+		"startLine": strconv.Itoa(startLine),
+		"endLine":   strconv.Itoa(endLine),
+	}), nil
+}
+
+// BlameURL returns the link to the blame of the given file at the given commit
+// on the remote's git service
+func (pr *PullRequest) BlameURL(sha string, path string) (string, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getDefaultRemoteName())
+	if err != nil {
+		return "", err
+	}
+
+	if gitService.BlameURL == "" {
+		return "", errors.New(pr.GitCommand.Tr.BlameUnsupported)
+	}
+
+	return resolveRepoPlaceholders(gitService.BlameURL, repoInfo, map[string]string{
+		"sha":  sha,
+		"path": encodePathValue(strings.TrimPrefix(filepath.ToSlash(path), "/")),
+	}), nil
+}
+
+// NewIssueURL returns the link to the form for opening a new issue on the
+// remote's git service
+func (pr *PullRequest) NewIssueURL() (string, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getDefaultRemoteName())
+	if err != nil {
+		return "", err
+	}
+
+	if gitService.NewIssueURL == "" {
+		return "", errors.New(pr.GitCommand.Tr.NewIssueUnsupported)
+	}
+
+	return resolveRepoPlaceholders(gitService.NewIssueURL, repoInfo, nil), nil
+}
+
+// PipelinesURL returns the link to the CI runs of the given branch on the
+// remote's git service
+func (pr *PullRequest) PipelinesURL(branch string) (string, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getDefaultRemoteName())
+	if err != nil {
+		return "", err
+	}
+
+	if gitService.PipelinesURL == "" {
+		return "", errors.New(pr.GitCommand.Tr.PipelinesUnsupported)
+	}
+
+	pipelinesURL := encodeBranchPlaceholders(gitService.PipelinesURL, map[string]string{
+		"branch": branch,
+	})
+
+	return resolveRepoPlaceholders(pipelinesURL, repoInfo, nil), nil
+}
+
+// TagURL returns the link to the page of the given tag on the remote's git
+// service
+func (pr *PullRequest) TagURL(tag string) (string, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getDefaultRemoteName())
+	if err != nil {
+		return "", err
+	}
+
+	if gitService.TagURL == "" {
+		return "", errors.New(pr.GitCommand.Tr.TagURLUnsupported)
+	}
+
+	// tags can contain slashes just like branches, e.g. 'release/1.0'
+	tagURL := encodeBranchPlaceholders(gitService.TagURL, map[string]string{
+		"tag": tag,
+	})
+
+	return resolveRepoPlaceholders(tagURL, repoInfo, nil), nil
+}
+
+func (pr *PullRequest) checkBranchExistsOnRemote(branch *models.Branch) error {
+	if !pr.GitCommand.CheckRemoteBranchExists(pr.getRemoteName(branch), branch) {
+		return errors.New(pr.GitCommand.Tr.NoBranchOnRemote)
+	}
+
+	return nil
+}
+
+func (pr *PullRequest) getPullRequestURL(branch *models.Branch, opts PullRequestOptions) (string, error) {
+	gitService, repoInfo, err := pr.getRemoteService(pr.getRemoteName(branch))
+	if err != nil {
+		return "", err
+	}
+
+	target := pr.getTargetBranch(gitService, branch, opts)
+
+	// the host may know the branch by another name than we do
+	urlBranch := *branch
+	urlBranch.Name = pr.GitCommand.Config.GetUserConfig().PR.BranchNameRewrite.Rewrite(branch.Name)
+
+	if gitService.PullRequestURLTemplate != "" {
+		return utils.ResolveTemplate(gitService.PullRequestURLTemplate, pullRequestURLTemplateArgs{
+			Owner:        repoInfo.Owner,
+			Project:      repoInfo.Project,
+			Repository:   repoInfo.Repository,
+			Branch:       urlBranch.Name,
+			TargetBranch: target,
+			Host:         gitService.Host,
+			Draft:        opts.Draft,
+			Title:        opts.Title,
+			Body:         opts.Body,
+		})
+	}
+
+	if gitService.PullRequestURL == "" {
+		return "", errors.New(pr.GitCommand.Tr.UnsupportedGitService)
+	}
+
+	urlTemplate := gitService.PullRequestURL
+	if target != "" {
+		urlTemplate = gitService.PullRequestURLIntoTargetBranch
+	}
+
+	if pr.GitCommand.Config.GetUserConfig().PR.AppendFormParam {
+		urlTemplate += gitService.FormParam
+	}
+
+	title := opts.Title
+	if opts.Draft {
+		if gitService.DraftPullRequestParam == "" {
+			return "", errors.New(pr.GitCommand.Tr.DraftPullRequestsUnsupported)
+		}
+		if title != "" && gitService.DraftTitlePrefix != "" {
+			title = gitService.DraftTitlePrefix + title
+		} else {
+			urlTemplate += gitService.DraftPullRequestParam
+		}
+	}
+
+	if title != "" {
+		urlTemplate += gitService.TitleParam
+	}
+
+	if opts.Body != "" {
+		urlTemplate += gitService.BodyParam
+	}
+
+	forkOwner := repoInfo.Owner
+	repoInfo, head := pr.getForkHead(gitService, repoInfo, &urlBranch)
+
+	targetProject := ""
+	if gitService.TargetProjectIDParam != "" {
+		targetProject, err = pr.getTargetProjectID(gitService, repoInfo, opts.TargetProject)
+		if err != nil {
+			return "", err
+		}
+		if targetProject != "" {
+			urlTemplate += gitService.TargetProjectIDParam
+		}
+	}
+
+	branches := map[string]string{
+		"branch":       head,
+		"targetBranch": target,
+	}
+	if head != urlBranch.Name {
+		// we encode either half of a '<fork-owner>:<branch>' head on its own so
+		// that the colon between them is still read as the fork's owner
+		urlTemplate = strings.Replace(urlTemplate, "{{branch}}", "{{forkOwner}}:{{branch}}", -1)
+		branches["branch"] = urlBranch.Name
+		branches["forkOwner"] = forkOwner
+	}
+
+	urlTemplate = encodeBranchPlaceholders(urlTemplate, branches)
+
+	pullRequestURL := utils.ResolvePlaceholderString(
+		urlTemplate, map[string]string{
+			"owner":         repoInfo.Owner,
+			"project":       repoInfo.Project,
+			"repository":    repoInfo.Repository,
+			"title":         encodeQueryValue(title),
+			"body":          encodeQueryValue(opts.Body),
+			"targetProject": encodeQueryValue(targetProject),
+		},
+	)
+
+	return pullRequestURL, nil
+}
+
+// getTargetProject returns the project a cross-project merge request should
+// target: the given one if any, otherwise the upstream remote's project if it
+// isn't our own. It returns an empty string for a merge request within our
+// own project
+func (pr *PullRequest) getTargetProject(gitService *Service, repoInfo *RepoInformation, targetProject string) string {
+	if targetProject != "" {
+		return targetProject
+	}
+
+	upstreamRepoInfo := pr.getUpstreamRepoInfo(gitService)
+	if upstreamRepoInfo == nil {
+		return ""
+	}
+
+	if upstreamRepoInfo.Owner == repoInfo.Owner && upstreamRepoInfo.Repository == repoInfo.Repository {
+		return ""
+	}
+
+	return upstreamRepoInfo.Owner + "/" + upstreamRepoInfo.Repository
+}
+
+// getTargetProjectID returns the numeric ID of the project a cross-project
+// merge request should target, or an empty string for a merge request within
+// our own project. If we can't look up the ID of the upstream remote's project
+// we leave it to the service, which targets the project a fork was forked from,
+// but a project the user asked for has to be found
+func (pr *PullRequest) getTargetProjectID(gitService *Service, repoInfo *RepoInformation, targetProject string) (string, error) {
+	project := pr.getTargetProject(gitService, repoInfo, targetProject)
+	if project == "" {
+		return "", nil
+	}
+
+	if _, err := strconv.Atoi(project); err == nil {
+		return project, nil
+	}
+
+	id, err := pr.lookUpProjectID(gitService, project)
+	if err != nil {
+		if targetProject != "" {
+			return "", errors.New(fmt.Sprintf(pr.GitCommand.Tr.TargetProjectNotFound, project, err.Error()))
+		}
+		pr.GitCommand.Log.Error(err)
+		return "", nil
+	}
+
+	return id, nil
+}
+
+// lookUpProjectID returns the numeric ID of the project at the given path,
+// e.g. 'mathcorp/calculator', as printed by the service's ProjectIDCmd
+func (pr *PullRequest) lookUpProjectID(gitService *Service, projectPath string) (string, error) {
+	if gitService.ProjectIDCmd == "" {
+		return "", errors.New(pr.GitCommand.Tr.UnsupportedGitService)
+	}
+
+	cli := strings.Fields(gitService.ProjectIDCmd)[0]
+	if _, err := pr.GitCommand.OSCommand.LookPath(cli); err != nil {
+		return "", err
+	}
+
+	command := utils.ResolvePlaceholderString(gitService.ProjectIDCmd, map[string]string{
+		"host":        gitService.Host,
+		"projectPath": url.PathEscape(projectPath),
+	})
+
+	output, err := pr.GitCommand.OSCommand.RunCommandWithOutputWithOptions(command, pr.getCLIOptions())
+	if err != nil {
+		return "", err
+	}
+
+	var project struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(output), &project); err != nil {
+		return "", err
+	}
+	if project.ID == 0 {
+		return "", errors.New(fmt.Sprintf("no project id in %s", output))
+	}
+
+	return strconv.Itoa(project.ID), nil
+}
+
+// ListPRCapableRemotes returns the names of the remotes, as listed by 'git
+// remote -v', which we can open pull requests on, so that the user can pick one
+// to set as RemoteName. Remotes without a git service we know of, or on a
+// service without pull requests, are left out
+func (pr *PullRequest) ListPRCapableRemotes() ([]string, error) {
+	output, err := pr.GitCommand.OSCommand.RunCommandWithOutput("git remote -v")
+	if err != nil {
+		return nil, err
+	}
+
+	remoteNames := []string{}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		// e.g. 'origin	git@github.com:peter/calculator.git (fetch)'
+		fields := strings.Fields(line)
+		if len(fields) < 2 || seen[fields[0]] {
+			continue
+		}
+		// we take the first url of each remote, which is the one it fetches from
+		seen[fields[0]] = true
+
+		repoURL := pr.GitCommand.undoURLRewrite(fields[1])
+		repoInfo, parseErr := getRepoInfoFromURL(repoURL)
+		gitService, _, err := pr.getURLService(fields[0], repoURL, repoInfo, parseErr)
+		if err != nil || !gitService.canCreatePullRequests() {
+			continue
+		}
+
+		remoteNames = append(remoteNames, fields[0])
+	}
+
+	return remoteNames, nil
+}
+
+// canCreatePullRequests returns false for services we have no way of creating
+// pull requests on, e.g. one registered with RegisterServiceType which only
+// links to repos and commits
+func (s *Service) canCreatePullRequests() bool {
+	return s.PullRequestURL != "" || s.PullRequestURLTemplate != "" || s.UsesArcDiff
+}
+
+// getRemoteService returns the git service and repo information of the given
+// remote
+func (pr *PullRequest) getRemoteService(remoteName string) (*Service, *RepoInformation, error) {
+	repoURL, repoInfo, parseErr := pr.GitCommand.getRemoteRepoInfo(remoteName)
+	if repoURL == "" {
+		return nil, nil, parseErr
+	}
+
+	return pr.getURLService(remoteName, repoURL, repoInfo, parseErr)
+}
+
+// getURLService is getRemoteService for the remote with the given url, along
+// with the repo information parsed from it or the error we hit parsing it
+func (pr *PullRequest) getURLService(remoteName string, repoURL string, repoInfo *RepoInformation, parseErr error) (*Service, *RepoInformation, error) {
+	if isLocalRemoteURL(repoURL) {
+		return nil, nil, errors.New(fmt.Sprintf(pr.GitCommand.Tr.LocalRemoteUnsupported, remoteName))
+	}
+
+	if host, path := splitRemoteURL(repoURL); host == launchpadDomain {
+		path = strings.TrimSuffix(strings.TrimRight(path, "/"), ".git")
+		return nil, nil, errors.New(fmt.Sprintf(pr.GitCommand.Tr.LaunchpadUnsupported, launchpadWebDomain+"/"+path))
+	}
+
+	if isGistRemoteURL(repoURL) {
+		return nil, nil, errors.New(fmt.Sprintf(pr.GitCommand.Tr.GistRemoteUnsupported, remoteName))
+	}
+
+	if isWikiRemoteURL(repoURL) {
+		return nil, nil, errors.New(fmt.Sprintf(pr.GitCommand.Tr.WikiRemoteUnsupported, remoteName))
+	}
+
+	// e.g. git@:owner/repo.git, which would otherwise have us suggest adding a
+	// services entry for an empty host
+	if host, _ := splitRemoteURL(repoURL); host == "" {
+		return nil, nil, errors.New(fmt.Sprintf(pr.GitCommand.Tr.RemoteURLWithoutHost, remoteName, stripCredentials(repoURL)))
+	}
+
+	gitService, err := pr.findGitService(repoURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if parseErr != nil {
+		return nil, nil, errors.New(fmt.Sprintf(pr.GitCommand.Tr.InvalidRemoteURL, remoteName, parseErr.Error()))
+	}
+
+	if gitService.normaliseRepoInfo != nil {
+		repoInfo = gitService.normaliseRepoInfo(repoInfo)
+	}
+
+	if gitService.BasePath != "" {
+		repoInfo = stripBasePath(repoInfo, gitService.BasePath)
+	}
+
+	return gitService, repoInfo, nil
+}
+
+// isGistRemoteURL returns true for remotes of a gist, like
+// git@gist.github.com:1234abcd.git
+func isGistRemoteURL(url string) bool {
+	host, _ := splitRemoteURL(url)
+	return strings.HasPrefix(host, "gist.")
+}
+
+// isWikiRemoteURL returns true for remotes of a repo's wiki, like
+// git@github.com:owner/repo.wiki.git or, on Bitbucket,
+// https://bitbucket.org/owner/repo.git/wiki
+func isWikiRemoteURL(url string) bool {
+	_, path := splitRemoteURL(url)
+	path = strings.TrimRight(path, "/")
+	return strings.HasSuffix(strings.TrimSuffix(path, ".git"), ".wiki") || strings.HasSuffix(path, ".git/wiki")
+}
+
+// stripBasePath drops the base path of a service from the owner of a remote
+// like https://corp.net/gitlab/owner/repo.git, given it's already part of the
+// service's URLs
+func stripBasePath(repoInfo *RepoInformation, basePath string) *RepoInformation {
+	if !strings.HasPrefix(repoInfo.Owner, basePath+"/") {
+		return repoInfo
+	}
+
+	return &RepoInformation{
+		Owner:      strings.TrimPrefix(repoInfo.Owner, basePath+"/"),
+		Project:    repoInfo.Project,
+		Repository: repoInfo.Repository,
+	}
+}
+
+// getDefaultRemoteName returns the remote we build links from for anything
+// that isn't tied to a branch
+func (pr *PullRequest) getDefaultRemoteName() string {
+	if pr.RemoteName != "" {
+		return pr.RemoteName
+	}
+
+	return "origin"
+}
+
+func (pr *PullRequest) findGitService(repoURL string) (*Service, error) {
+	if service := matchService(pr.GitServices, repoURL); service != nil {
+		return service, nil
+	}
+
+	if defaultService := pr.GitCommand.Config.GetUserConfig().DefaultService; defaultService != "" {
+		if host, _ := splitRemoteURL(repoURL); host != "" {
+			if service := NewService(defaultService, host, host); service != nil {
+				return service, nil
+			}
+		}
+	}
+
+	return nil, newErrUnsupportedGitService(repoURL, pr.GitCommand.Tr.UnsupportedGitService)
+}
+
+func newErrUnsupportedGitService(remoteURL string, message string) *ErrUnsupportedGitService {
+	host, _ := splitRemoteURL(remoteURL)
+
+	return &ErrUnsupportedGitService{
+		Host:    host,
+		message: message,
+	}
+}
+
+func (pr *PullRequest) getRemoteName(branch *models.Branch) string {
+	if pr.RemoteName != "" {
+		return pr.RemoteName
+	}
+
+	if remoteName := pr.GitCommand.GetConfigValue(fmt.Sprintf("branch.%s.remote", branch.Name)); remoteName != "" {
+		return remoteName
+	}
+
+	return "origin"
+}
+
+// getTargetBranch returns the branch a pull request of the given branch merges
+// into, or an empty string for the repo's default branch
+func (pr *PullRequest) getTargetBranch(gitService *Service, branch *models.Branch, opts PullRequestOptions) string {
+	target := opts.Target
+	if target == "" {
+		target = pr.getConfiguredBase(gitService, opts.repoConfig)
+	}
+	if target == "" {
+		target = pr.getUpstreamBase(branch)
+	}
+	if target == "" && gitService.RequiresTargetBranch {
+		target = pr.GitCommand.GetDefaultBranch()
+	}
+
+	return target
+}
+
+// getConfiguredBase returns the base branch the given repo config, loaded
+// here if nil, configures for its pull requests, falling back to the service's
+// DefaultBase and then to PR.DefaultBase. It returns an empty string to have
+// the base detected
+func (pr *PullRequest) getConfiguredBase(gitService *Service, repoConfig *config.RepoConfig) string {
+	if repoConfig == nil {
+		repoConfig = pr.loadRepoConfig()
+	}
+	if repoConfig.PR.DefaultBase != "" {
+		return repoConfig.PR.DefaultBase
+	}
+
+	if gitService.DefaultBase != "" {
+		return gitService.DefaultBase
+	}
+
+	return pr.GitCommand.Config.GetUserConfig().PR.DefaultBase
+}
+
+// loadRepoConfig loads the repo's RepoConfigFilename, returning an empty config
+// if it doesn't have one or we can't make sense of it
+func (pr *PullRequest) loadRepoConfig() *config.RepoConfig {
+	root, err := pr.GitCommand.RepoRootDir()
+	if err != nil {
+		return &config.RepoConfig{}
+	}
+
+	repoConfig, err := config.LoadRepoConfig(root, pr.GitCommand.readFile)
+	if err != nil {
+		// a broken repo config shouldn't stop us opening pull requests
+		pr.GitCommand.Log.Error(err)
+		return &config.RepoConfig{}
+	}
+
+	return repoConfig
+}
+
+// getForkHead returns the repo a pull request of the given branch is opened on
+// along with its head. That's the upstream repo if we're on a fork of it, with
+// a '<fork-owner>:<branch>' head, otherwise our own repo and the branch itself
+func (pr *PullRequest) getForkHead(gitService *Service, repoInfo *RepoInformation, branch *models.Branch) (*RepoInformation, string) {
+	if gitService.SupportsForks {
+		if upstreamRepoInfo := pr.getUpstreamRepoInfo(gitService); upstreamRepoInfo != nil && upstreamRepoInfo.Owner != repoInfo.Owner {
+			return upstreamRepoInfo, repoInfo.Owner + ":" + branch.Name
+		}
+	}
+
+	return repoInfo, branch.Name
+}
+
+// getUpstreamBase returns the branch that the given branch tracks, e.g.
+// 'release-2.0' for a branch tracking origin/release-2.0, for use as the default
+// base of its pull requests. If the branch tracks a branch of the same name,
+// which is just where it's pushed to, or tracks nothing, it returns an empty
+// string so that the repo's default branch is used instead
+func (pr *PullRequest) getUpstreamBase(branch *models.Branch) string {
+	mergeRef := pr.GitCommand.GetConfigValue(fmt.Sprintf("branch.%s.merge", branch.Name))
+	base := strings.TrimPrefix(mergeRef, "refs/heads/")
+	if base == branch.Name {
+		return ""
+	}
+
+	return base
+}
+
+// getUpstreamRepoInfo returns the repo information of the upstream remote if
+// there is one on the same service, otherwise nil. We match the upstream url to
+// a service as we do origin's, and its host has to be the service's git domain
+// or a subdomain of it, so that e.g. notgithub.com.evil isn't taken for GitHub
+func (pr *PullRequest) getUpstreamRepoInfo(gitService *Service) *RepoInformation {
+	upstreamURL, upstreamRepoInfo, err := pr.GitCommand.getRemoteRepoInfo(upstreamRemoteName)
+	if err != nil {
+		return nil
+	}
+
+	host, _ := splitRemoteURL(upstreamURL)
+	domain := strings.ToLower(gitService.Name)
+	if host != domain && !strings.HasSuffix(host, "."+domain) {
+		return nil
+	}
+
+	// a subdomain configured as a service of its own is another service though
+	if service := matchService(pr.GitServices, upstreamURL); service != nil && service.Name != gitService.Name {
+		return nil
+	}
+
+	return upstreamRepoInfo
+}
+
+// encodeQueryValue percent-encodes a value for use in a url's query string,
+// encoding spaces as '%20' rather than '+' which not every service decodes
+func encodeQueryValue(value string) string {
+	return strings.Replace(url.QueryEscape(value), "+", "%20", -1)
+}
+
+// encodePathValue percent-encodes a value for use in a url's path, leaving its
+// slashes be so that e.g. a branch like feature/x stays readable. Colons are
+// encoded too, as github reads x:y in a compare path as a fork's owner:branch
+func encodePathValue(value string) string {
+	segments := strings.Split(value, "/")
+	for i, segment := range segments {
+		segments[i] = strings.Replace(url.PathEscape(segment), ":", "%3A", -1)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// encodeBranchPlaceholders resolves the given branch placeholders of a url
+// template, encoding each branch name for the part of the url it's in: slashes
+// are left alone in the path but encoded in the query string
+func encodeBranchPlaceholders(urlTemplate string, branches map[string]string) string {
+	path, query := urlTemplate, ""
+	if queryIndex := strings.Index(urlTemplate, "?"); queryIndex != -1 {
+		path, query = urlTemplate[:queryIndex], urlTemplate[queryIndex:]
+	}
+
+	pathValues := map[string]string{}
+	queryValues := map[string]string{}
+	for placeholder, branch := range branches {
+		pathValues[placeholder] = encodePathValue(branch)
+		queryValues[placeholder] = encodeQueryValue(branch)
+	}
+
+	return utils.ResolvePlaceholderString(path, pathValues) + utils.ResolvePlaceholderString(query, queryValues)
+}
+
+// resolveRepoPlaceholders resolves the {{owner}}, {{project}} and {{repository}}
+// placeholders of a service's template along with any extra ones given
+func resolveRepoPlaceholders(template string, repoInfo *RepoInformation, extra map[string]string) string {
+	placeholders := map[string]string{
+		"owner":      repoInfo.Owner,
+		"project":    repoInfo.Project,
+		"repository": repoInfo.Repository,
+	}
+	for key, value := range extra {
+		placeholders[key] = value
+	}
+
+	return utils.ResolvePlaceholderString(template, placeholders)
+}
+
+// getRepoInfoFromURL parses the owner and repository (and for Azure DevOps, the
+// project) out of a remote url, returning an error if the url doesn't have them
+func getRepoInfoFromURL(url string) (*RepoInformation, error) {
+	if isLocalRemoteURL(url) {
+		return nil, errors.New("remote url " + url + " is a local path")
+	}
+
+	url, err := unwrapRemoteURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	host, path := splitRemoteURL(url)
+	// scp-like urls may or may not have a slash after the colon, e.g.
+	// git@github.com:/owner/repo.git
+	path = strings.TrimLeft(path, "/")
+	// tooling sometimes leaves a trailing slash on either side of the '.git'
+	path = strings.TrimRight(path, "/")
+	path = strings.TrimRight(strings.TrimSuffix(path, ".git"), "/")
+
+	var repoInfo *RepoInformation
+	if host == azureDevOpsDomain || strings.HasSuffix(host, "."+azureDevOpsDomain) {
+		repoInfo = getAzureDevOpsRepoInfoFromPath(path)
+	} else if isCodeCommitHost(host) {
+		repoInfo = getCodeCommitRepoInfo(host, path)
+	} else {
+		splits := strings.Split(path, "/")
+		repoInfo = &RepoInformation{
+			Owner:      strings.Join(splits[0:len(splits)-1], "/"),
+			Repository: splits[len(splits)-1],
+		}
+	}
+
+	if repoInfo.Owner == "" || repoInfo.Repository == "" {
+		return nil, errors.New("could not find an owner and repository in remote url " + stripCredentials(url))
+	}
+
+	if host == "" {
+		return nil, errors.New("could not find a host in remote url " + stripCredentials(url))
+	}
+
+	return repoInfo, nil
+}
+
+// unwrapRemoteURL returns the url wrapped in the query string of a remote url
+// like https://sso.corp.net/login?next=https://github.corp.net/owner/repo.git,
+// as produced by misconfigured credential helpers. Other urls are returned as
+// they are
+func unwrapRemoteURL(remoteURL string) (string, error) {
+	queryIndex := strings.Index(remoteURL, "?")
+	if queryIndex == -1 || !strings.Contains(remoteURL[:queryIndex], "://") {
+		return remoteURL, nil
+	}
+
+	query, err := url.ParseQuery(remoteURL[queryIndex+1:])
+	if err != nil {
+		return remoteURL, nil
+	}
+
+	wrappedURLs := map[string]bool{}
+	for _, values := range query {
+		for _, value := range values {
+			if strings.Contains(value, "://") {
+				wrappedURLs[value] = true
+			}
+		}
+	}
+
+	switch len(wrappedURLs) {
+	case 0:
+		return remoteURL, nil
+	case 1:
+		for wrappedURL := range wrappedURLs {
+			return wrappedURL, nil
+		}
+	}
+
+	return "", errors.New("remote url " + stripCredentials(remoteURL) + " wraps more than one url, so we can't tell which is the repo's")
+}
+
+// isLocalRemoteURL returns true for remotes which are paths on this machine
+// rather than repos on a git service, e.g. file:///C:/repos/project,
+// C:\repos\project or ../project. Windows drive letters in particular would
+// otherwise be mistaken for the host of an scp-like url
+func isLocalRemoteURL(url string) bool {
+	if strings.HasPrefix(url, "file://") {
+		return true
+	}
+
+	if len(url) >= 2 && url[1] == ':' && isASCIILetter(url[0]) &&
+		(len(url) == 2 || url[2] == '\\' || url[2] == '/') {
+		return true
+	}
+
+	// '//host/path' is a url without a scheme, whereas '\\server\share' is a
+	// Windows network path
+	if strings.HasPrefix(url, "//") {
+		return false
+	}
+
+	for _, prefix := range []string{"/", "./", "../", "~", "\\", ".\\", "..\\"} {
+		if strings.HasPrefix(url, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isASCIILetter(char byte) bool {
+	return ('a' <= char && char <= 'z') || ('A' <= char && char <= 'Z')
+}
+
+// splitRemoteURL splits a remote url into its host and its path, dropping any
+// scheme, user info and port along the way. It handles both URLs like
+// ssh://git@host:2222/owner/repo.git or https://user@host/owner/repo.git (including
+// compound schemes like git+ssh:// and schemeless ones like //host/owner/repo.git)
+// and the scp-like syntax git@host:owner/repo.git. The host is lowercased, given
+// hosts are case-insensitive, whereas the path is returned as is. IPv6 hosts
+// keep their brackets, as in git@[fd00::5]:owner/repo.git
+func splitRemoteURL(url string) (string, string) {
+	var hostPart, path string
+
+	if rest, ok := stripScheme(url); ok {
+		hostPart = rest
+		if pathIndex := strings.Index(rest, "/"); pathIndex != -1 {
+			hostPart = rest[:pathIndex]
+			path = rest[pathIndex+1:]
+		}
+	} else if colonIndex := scpColonIndex(url); colonIndex != -1 {
+		hostPart = url[:colonIndex]
+		path = url[colonIndex+1:]
+	} else {
+		path = url
+	}
+
+	if atIndex := strings.LastIndex(hostPart, "@"); atIndex != -1 {
+		hostPart = hostPart[atIndex+1:]
+	}
+
+	if strings.HasPrefix(hostPart, "[") {
+		if closeIndex := strings.Index(hostPart, "]"); closeIndex != -1 {
+			hostPart = hostPart[:closeIndex+1]
+		}
+	} else if portIndex := strings.Index(hostPart, ":"); portIndex != -1 {
+		hostPart = hostPart[:portIndex]
+	}
+
+	return strings.ToLower(hostPart), path
+}
+
+// scpColonIndex returns the index of the colon separating the host from the
+// path of an scp-like url, skipping over the colons of a bracketed IPv6 host,
+// or -1 if there is none
+func scpColonIndex(url string) int {
+	colonIndex := strings.Index(url, ":")
+	openIndex := strings.Index(url, "[")
+	if openIndex == -1 || colonIndex < openIndex {
+		return colonIndex
+	}
+
+	closeIndex := strings.Index(url, "]")
+	if closeIndex < openIndex {
+		return colonIndex
+	}
+
+	if afterIndex := strings.Index(url[closeIndex:], ":"); afterIndex != -1 {
+		return closeIndex + afterIndex
+	}
+
+	return -1
+}
+
+// bracketIPv6 puts a bare IPv6 address like 'fd00::5' in the brackets it needs
+// in urls, leaving any other host as it is
+func bracketIPv6(host string) string {
+	if strings.Contains(host, ":") && net.ParseIP(host) != nil {
+		return "[" + host + "]"
+	}
+
+	return host
+}
+
+// isIPAddress returns true for an IPv4 address or a bracketed IPv6 one
+func isIPAddress(host string) bool {
+	return net.ParseIP(host) != nil || config.IsBracketedIPv6(host)
+}
+
+// stripCredentials drops the user info from a url like
+// https://x-access-token:<token>@github.com/owner/repo.git so that we never
+// show the token to the user. scp-like urls are returned as is, given their
+// user (typically 'git') can't carry a password
+func stripCredentials(url string) string {
+	rest, ok := stripScheme(url)
+	if !ok {
+		return url
+	}
+
+	hostPart := rest
+	if pathIndex := strings.Index(rest, "/"); pathIndex != -1 {
+		hostPart = rest[:pathIndex]
+	}
+
+	atIndex := strings.LastIndex(hostPart, "@")
+	if atIndex == -1 {
+		return url
+	}
+
+	return url[:len(url)-len(rest)] + rest[atIndex+1:]
+}
+
+// stripScheme returns what follows the '//' of a url like scheme://host/path or
+// //host/path, or false if the url has no '//'
+func stripScheme(url string) (string, bool) {
+	if strings.HasPrefix(url, "//") {
+		return url[len("//"):], true
+	}
+
+	if schemeIndex := strings.Index(url, "://"); schemeIndex != -1 {
+		return url[schemeIndex+len("://"):], true
+	}
+
+	return "", false
+}
+
+// Bitbucket Server remotes come in one of two forms:
+// ssh://git@<host>:7999/<project key>/<repo>.git
+// https://<host>/scm/<project key>/<repo>.git
+// so the project key is the first segment of the path after any 'scm' prefix
+func getBitbucketServerRepoInfo(repoInfo *RepoInformation) *RepoInformation {
+	segments := strings.Split(repoInfo.Owner, "/")
+
+	return &RepoInformation{
+		Owner:      segments[len(segments)-1],
+		Repository: repoInfo.Repository,
+	}
+}
+
+// Azure DevOps remotes come in one of two forms:
+// git@ssh.dev.azure.com:v3/<org>/<project>/<repo>
+// https://<org>@dev.azure.com/<org>/<project>/_git/<repo>
+func getAzureDevOpsRepoInfoFromPath(path string) *RepoInformation {
+	segments := strings.Split(path, "/")
+	if len(segments) != 4 {
+		return &RepoInformation{}
+	}
+
+	owner, project := segments[0], segments[1]
+	// ssh paths start with the API version rather than having a '_git' separator
+	if segments[2] != "_git" {
+		owner, project = segments[1], segments[2]
+	}
+
+	return &RepoInformation{
+		Owner:      owner,
+		Project:    project,
+		Repository: strings.TrimSuffix(segments[3], ".git"),
+	}
+}
+
+// CodeCommit remotes look like
+// ssh://git-codecommit.<region>.amazonaws.com/v1/repos/<repo> or the https
+// equivalent. We put the region in the owner, see NewService
+func getCodeCommitRepoInfo(host string, path string) *RepoInformation {
+	segments := strings.Split(path, "/")
+	if len(segments) != 3 || segments[1] != "repos" {
+		return &RepoInformation{}
+	}
+
+	region := strings.TrimSuffix(strings.TrimPrefix(host, codeCommitHostPrefix), "."+codeCommitDomain)
+
+	return &RepoInformation{
+		Owner:      region,
+		Repository: segments[2],
+	}
+}
+
+// parseGitHubPullRequests parses the output of 'gh pr list --json number,title,state,author,headRefName,baseRefName,url'
+func parseGitHubPullRequests(output string) ([]*models.PullRequest, error) {
+	var ghPullRequests []struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		State  string `json:"state"`
+		Author struct {
+			Login string `json:"login"`
+		} `json:"author"`
+		HeadRefName string `json:"headRefName"`
+		BaseRefName string `json:"baseRefName"`
+		URL         string `json:"url"`
+	}
+
+	if err := json.Unmarshal([]byte(output), &ghPullRequests); err != nil {
+		return nil, err
+	}
+
+	pullRequests := make([]*models.PullRequest, len(ghPullRequests))
+	for i, ghPullRequest := range ghPullRequests {
+		pullRequests[i] = models.NewPullRequest(
+			ghPullRequest.Number,
+			ghPullRequest.Title,
+			normalisePullRequestState(ghPullRequest.State),
+			ghPullRequest.Author.Login,
+			ghPullRequest.HeadRefName,
+			ghPullRequest.BaseRefName,
+			ghPullRequest.URL,
+		)
+	}
+
+	return pullRequests, nil
+}
+
+// parseGitLabMergeRequests parses the output of 'glab mr list --output json'
+func parseGitLabMergeRequests(output string) ([]*models.PullRequest, error) {
+	var glabMergeRequests []struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		State  string `json:"state"`
+		Author struct {
+			Username string `json:"username"`
+		} `json:"author"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+		WebURL       string `json:"web_url"`
+	}
+
+	if err := json.Unmarshal([]byte(output), &glabMergeRequests); err != nil {
+		return nil, err
+	}
+
+	pullRequests := make([]*models.PullRequest, len(glabMergeRequests))
+	for i, glabMergeRequest := range glabMergeRequests {
+		pullRequests[i] = models.NewPullRequest(
+			glabMergeRequest.IID,
+			glabMergeRequest.Title,
+			normalisePullRequestState(glabMergeRequest.State),
+			glabMergeRequest.Author.Username,
+			glabMergeRequest.SourceBranch,
+			glabMergeRequest.TargetBranch,
+			glabMergeRequest.WebURL,
+		)
+	}
+
+	return pullRequests, nil
+}
+
+// normalisePullRequestState maps a service's pull request state, e.g. GitHub's
+// 'OPEN' or GitLab's 'opened', onto one of the models.PullRequestState values
+func normalisePullRequestState(state string) string {
+	switch strings.ToLower(state) {
+	case "open", "opened":
+		return models.PullRequestStateOpen
+	case "merged":
+		return models.PullRequestStateMerged
+	default:
+		return models.PullRequestStateClosed
 	}
 }
@@ -2,7 +2,6 @@ package commands
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -143,7 +142,7 @@ func (c *GitCommand) AmendTo(sha string) error {
 // EditRebaseTodo sets the action at a given index in the git-rebase-todo file
 func (c *GitCommand) EditRebaseTodo(index int, action string) error {
 	fileName := filepath.Join(c.DotGitDir, "rebase-merge/git-rebase-todo")
-	bytes, err := ioutil.ReadFile(fileName)
+	bytes, err := c.readFile(fileName)
 	if err != nil {
 		return err
 	}
@@ -158,7 +157,7 @@ func (c *GitCommand) EditRebaseTodo(index int, action string) error {
 	content[contentIndex] = action + " " + strings.Join(splitLine[1:], " ")
 	result := strings.Join(content, "\n")
 
-	return ioutil.WriteFile(fileName, []byte(result), 0644)
+	return c.writeFile(fileName, []byte(result), 0644)
 }
 
 func (c *GitCommand) getTodoCommitCount(content []string) int {
@@ -175,7 +174,7 @@ func (c *GitCommand) getTodoCommitCount(content []string) int {
 // MoveTodoDown moves a rebase todo item down by one position
 func (c *GitCommand) MoveTodoDown(index int) error {
 	fileName := filepath.Join(c.DotGitDir, "rebase-merge/git-rebase-todo")
-	bytes, err := ioutil.ReadFile(fileName)
+	bytes, err := c.readFile(fileName)
 	if err != nil {
 		return err
 	}
@@ -188,7 +187,7 @@ func (c *GitCommand) MoveTodoDown(index int) error {
 	rearrangedContent = append(rearrangedContent, content[contentIndex+1:]...)
 	result := strings.Join(rearrangedContent, "\n")
 
-	return ioutil.WriteFile(fileName, []byte(result), 0644)
+	return c.writeFile(fileName, []byte(result), 0644)
 }
 
 // SquashAllAboveFixupCommits squashes all fixup! commits above the given one
@@ -2,23 +2,31 @@ package commands
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/go-errors/errors"
 
 	"github.com/jesseduffield/lazygit/pkg/commands/models"
+	"github.com/jesseduffield/lazygit/pkg/config"
 )
 
 func (c *GitCommand) AddRemote(name string, url string) error {
+	defer c.ClearRemoteRepoInfoCache()
 	return c.OSCommand.RunCommand("git remote add %s %s", name, url)
 }
 
 func (c *GitCommand) RemoveRemote(name string) error {
+	defer c.ClearRemoteRepoInfoCache()
 	return c.OSCommand.RunCommand("git remote remove %s", name)
 }
 
 func (c *GitCommand) RenameRemote(oldRemoteName string, newRemoteName string) error {
+	defer c.ClearRemoteRepoInfoCache()
 	return c.OSCommand.RunCommand("git remote rename %s %s", oldRemoteName, newRemoteName)
 }
 
 func (c *GitCommand) UpdateRemoteUrl(remoteName string, updatedUrl string) error {
+	defer c.ClearRemoteRepoInfoCache()
 	return c.OSCommand.RunCommand("git remote set-url %s %s", remoteName, updatedUrl)
 }
 
@@ -28,16 +36,174 @@ func (c *GitCommand) DeleteRemoteBranch(remoteName string, branchName string, pr
 }
 
 // CheckRemoteBranchExists Returns remote branch
-func (c *GitCommand) CheckRemoteBranchExists(branch *models.Branch) bool {
+func (c *GitCommand) CheckRemoteBranchExists(remoteName string, branch *models.Branch) bool {
 	_, err := c.OSCommand.RunCommandWithOutput(
-		"git show-ref --verify -- refs/remotes/origin/%s",
+		"git show-ref --verify -- refs/remotes/%s/%s",
+		remoteName,
 		branch.Name,
 	)
 
 	return err == nil
 }
 
-// GetRemoteURL returns current repo remote url
-func (c *GitCommand) GetRemoteURL() string {
-	return c.GetConfigValue("remote.origin.url")
+// GetRemoteURL returns the url of the given remote, looking in the repo's git
+// config before the global one. See PR.RemoteURLSource for asking git instead
+func (c *GitCommand) GetRemoteURL(remoteName string) (string, error) {
+	if c.Config.GetUserConfig().PR.RemoteURLSource == config.PRRemoteURLSourceGit {
+		if url := c.getPushURL(remoteName); url != "" {
+			return url, nil
+		}
+	}
+
+	key := fmt.Sprintf("remote.%s.url", remoteName)
+
+	// we get an error if the key doesn't exist which we don't care about
+	if url, _ := c.getLocalGitConfig(key); url != "" {
+		return url, nil
+	}
+
+	if url, _ := c.getGlobalGitConfig(key); url != "" {
+		return url, nil
+	}
+
+	return "", errors.New(fmt.Sprintf(c.Tr.RemoteURLNotFound, remoteName))
+}
+
+// getPushURL asks git for the url it pushes the given remote to, or returns an
+// empty string if git doesn't know the remote. Of a remote with several urls we
+// take the first, which is where git pushes first
+func (c *GitCommand) getPushURL(remoteName string) string {
+	output, err := c.OSCommand.RunCommandWithOutput("git remote get-url --push %s", remoteName)
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if url := strings.TrimSpace(line); url != "" {
+			return url
+		}
+	}
+
+	return ""
+}
+
+// GetRepoInformation returns the owner and repository of the given remote,
+// parsed from its url
+func (c *GitCommand) GetRepoInformation(remoteName string) (*RepoInformation, error) {
+	_, repoInfo, err := c.getRemoteRepoInfo(remoteName)
+	return repoInfo, err
+}
+
+type remoteRepoInfo struct {
+	url      string
+	repoInfo *RepoInformation
+	err      error
+}
+
+// getRemoteRepoInfo returns the url of the given remote along with the repo
+// information parsed from it, or the error we hit parsing it. Looking up the
+// url means shelling out to git config, so we hold onto the result until a
+// remote changes
+func (c *GitCommand) getRemoteRepoInfo(remoteName string) (string, *RepoInformation, error) {
+	c.remoteRepoInfoMutex.Lock()
+	defer c.remoteRepoInfoMutex.Unlock()
+
+	if cached, ok := c.remoteRepoInfoCache[remoteName]; ok {
+		return cached.url, cached.repoInfo, cached.err
+	}
+
+	info := &remoteRepoInfo{}
+	info.url, info.err = c.GetRemoteURL(remoteName)
+	if info.err == nil {
+		info.url = c.undoURLRewrite(info.url)
+		info.repoInfo, info.err = getRepoInfoFromURL(info.url)
+	}
+
+	if c.remoteRepoInfoCache == nil {
+		c.remoteRepoInfoCache = map[string]*remoteRepoInfo{}
+	}
+	c.remoteRepoInfoCache[remoteName] = info
+
+	return info.url, info.repoInfo, info.err
+}
+
+// undoURLRewrite maps a url which a url.<base>.insteadOf rule rewrites to,
+// e.g. that of an internal mirror of github.com, back to the url it was
+// written as, so that we build links for the git service rather than the
+// mirror. As with git, the longest matching base wins
+func (c *GitCommand) undoURLRewrite(url string) string {
+	// we get an error if there are no rules, which we don't care about
+	output, _ := c.getGitConfigRegexp(`^url[.].*[.]insteadof$`)
+
+	base, insteadOf := "", ""
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+
+		key := fields[0]
+		if !strings.HasPrefix(key, "url.") || !strings.HasSuffix(strings.ToLower(key), ".insteadof") {
+			continue
+		}
+
+		ruleBase := key[len("url.") : len(key)-len(".insteadof")]
+		if ruleBase != "" && strings.HasPrefix(url, ruleBase) && len(ruleBase) > len(base) {
+			base, insteadOf = ruleBase, fields[1]
+		}
+	}
+
+	if base == "" {
+		return url
+	}
+
+	return insteadOf + strings.TrimPrefix(url, base)
+}
+
+// ClearRemoteRepoInfoCache forgets what we know about the remotes so that it's
+// looked up afresh next time. Changing a remote through GitCommand does this
+// for you, but remotes can also be changed outside of lazygit
+func (c *GitCommand) ClearRemoteRepoInfoCache() {
+	c.remoteRepoInfoMutex.Lock()
+	defer c.remoteRepoInfoMutex.Unlock()
+
+	c.remoteRepoInfoCache = nil
+	c.defaultBranch = ""
+	c.defaultBranchLoaded = false
+}
+
+// GetDefaultBranch returns the default branch of the repo, as pointed to by
+// origin's HEAD. If that isn't set (e.g. the repo was created with git init
+// rather than cloned) we fall back to main or master, whichever exists. An
+// empty string is returned if we can't work it out
+func (c *GitCommand) GetDefaultBranch() string {
+	c.remoteRepoInfoMutex.Lock()
+	defer c.remoteRepoInfoMutex.Unlock()
+
+	if !c.defaultBranchLoaded {
+		c.defaultBranch = c.findDefaultBranch()
+		c.defaultBranchLoaded = true
+	}
+
+	return c.defaultBranch
+}
+
+func (c *GitCommand) findDefaultBranch() string {
+	output, err := c.OSCommand.RunCommandWithOutput("git symbolic-ref refs/remotes/origin/HEAD")
+	if err == nil {
+		branchName := strings.TrimPrefix(strings.TrimSpace(output), "refs/remotes/origin/")
+		if branchName != "" {
+			return branchName
+		}
+	}
+
+	for _, ref := range []string{"refs/remotes/origin/%s", "refs/heads/%s"} {
+		for _, branchName := range []string{"main", "master"} {
+			if _, err := c.OSCommand.RunCommandWithOutput("git show-ref --verify -- "+ref, branchName); err == nil {
+				return branchName
+			}
+		}
+	}
+
+	return ""
 }
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"path/filepath"
+	"strings"
 
 	gogit "github.com/jesseduffield/go-git/v5"
 )
@@ -48,6 +49,38 @@ func (c *GitCommand) IsInMergeState() (bool, error) {
 	return c.OSCommand.FileExists(filepath.Join(c.DotGitDir, "MERGE_HEAD"))
 }
 
+// IsWorkingTreeClean returns whether there are no changes in the working tree,
+// untracked files included, that haven't been committed
+func (c *GitCommand) IsWorkingTreeClean() (bool, error) {
+	output, err := c.OSCommand.RunCommandWithOutput("git status --porcelain")
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(output) == "", nil
+}
+
+// RepoRootDir returns the root directory of the working tree. We move there on
+// startup unless we're given GIT_DIR, in which case git takes GIT_WORK_TREE, or
+// failing that the current directory, to be the root
+func (c *GitCommand) RepoRootDir() (string, error) {
+	cwd, err := c.OSCommand.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	workTree := c.OSCommand.Getenv("GIT_WORK_TREE")
+	if workTree == "" {
+		return cwd, nil
+	}
+
+	if filepath.IsAbs(workTree) {
+		return workTree, nil
+	}
+
+	return filepath.Join(cwd, workTree), nil
+}
+
 func (c *GitCommand) IsBareRepo() bool {
 	// note: could use `git rev-parse --is-bare-repository` if we wanna drop go-git
 	_, err := c.Repo.Worktree()
@@ -140,6 +140,10 @@ func loadUserConfig(configDir string, base *UserConfig) (*UserConfig, error) {
 		return nil, err
 	}
 
+	if err := base.Validate(); err != nil {
+		return nil, err
+	}
+
 	return base, nil
 }
 
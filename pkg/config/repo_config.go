@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	yaml "github.com/jesseduffield/yaml"
+)
+
+// RepoConfigFilename is the file in the root of a repo with which the repo can
+// override parts of the user config, e.g. to always base its pull requests on a
+// particular branch
+const RepoConfigFilename = ".lazygit.yml"
+
+// RepoConfig contains the parts of the user config a repo can override
+type RepoConfig struct {
+	PR RepoPRConfig `yaml:"pr"`
+}
+
+// RepoPRConfig overrides PRConfig for the repo
+type RepoPRConfig struct {
+	// DefaultBase overrides PRConfig.DefaultBase
+	DefaultBase string `yaml:"defaultBase"`
+}
+
+// LoadRepoConfig loads the RepoConfigFilename of the repo rooted at the given
+// directory with the given readFile, e.g. ioutil.ReadFile, returning an empty
+// config if the repo doesn't have one
+func LoadRepoConfig(repoRoot string, readFile func(string) ([]byte, error)) (*RepoConfig, error) {
+	repoConfig := &RepoConfig{}
+
+	content, err := readFile(filepath.Join(repoRoot, RepoConfigFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return repoConfig, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(content, repoConfig); err != nil {
+		return nil, err
+	}
+
+	return repoConfig, nil
+}
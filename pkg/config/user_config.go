@@ -1,5 +1,7 @@
 package config
 
+import "regexp"
+
 type UserConfig struct {
 	Gui                  GuiConfig        `yaml:"gui"`
 	Git                  GitConfig        `yaml:"git"`
@@ -14,7 +16,15 @@ type UserConfig struct {
 	DisableStartupPopups bool              `yaml:"disableStartupPopups"`
 	CustomCommands       []CustomCommand   `yaml:"customCommands"`
 	Services             map[string]string `yaml:"services"`
-	NotARepository       string            `yaml:"notARepository"`
+	// DefaultService is the provider (e.g. 'gitlab') we assume a remote's host
+	// belongs to when it matches neither Services nor a built-in service
+	DefaultService string `yaml:"defaultService"`
+	// PullRequestURLTemplates maps a git domain to a go template used to build
+	// its pull request URLs, overriding the built-in format of its service
+	PullRequestURLTemplates map[string]string `yaml:"pullRequestURLTemplates"`
+	// PR determines how pull requests are created
+	PR             PRConfig `yaml:"pr"`
+	NotARepository string   `yaml:"notARepository"`
 }
 
 type GuiConfig struct {
@@ -229,13 +239,189 @@ type KeybindingSubmodulesConfig struct {
 	BulkMenu string `yaml:"bulkMenu"`
 }
 
+// PRConfig contains config relating to creating pull requests
+type PRConfig struct {
+	// AppendFormParam determines whether we append Bitbucket's 't=1' parameter to
+	// its pull request URLs, which takes you straight to the pull request form
+	// rather than to the comparison of the branches
+	AppendFormParam bool `yaml:"appendFormParam"`
+
+	// OpenAttempts is how many times we try to open a pull request in the
+	// browser before giving up, for browser launchers which fail transiently
+	OpenAttempts int `yaml:"openAttempts"`
+
+	// OpenRetryDelay is how many milliseconds we wait before the first retry.
+	// Each subsequent retry waits twice as long as the previous one
+	OpenRetryDelay int `yaml:"openRetryDelay"`
+
+	// OpenMode is what we do with a new pull request's URL: PROpenModeBrowser
+	// opens it in the browser and PROpenModeClipboard copies it to the clipboard
+	OpenMode string `yaml:"openMode"`
+
+	// UseCLIWhenAvailable determines whether we create pull requests with the
+	// git service's CLI (e.g. gh or glab) when it's installed, opening the
+	// pull request it creates rather than the web form
+	UseCLIWhenAvailable bool `yaml:"useCLIWhenAvailable"`
+
+	// ClipboardFormat is how we copy pull request URLs to the clipboard:
+	// PRClipboardFormatPlain copies the URL as is and PRClipboardFormatMarkdown
+	// copies a markdown link like [owner/repo#branch](url)
+	ClipboardFormat string `yaml:"clipboardFormat"`
+
+	// OpenInPrivateWindow determines whether we open pull requests in a private
+	// window, for browsers whose flag for one we know. See
+	// OSConfig.PrivateWindowFlags
+	OpenInPrivateWindow bool `yaml:"openInPrivateWindow"`
+
+	// OpenWorkingDir is the directory we run the open link command in when
+	// opening pull requests, for scripts which expect to be run from somewhere
+	// in particular. Relative paths are taken from the repo root, which is
+	// where the command runs if this is empty
+	OpenWorkingDir string `yaml:"openWorkingDir"`
+
+	// RemoteURLSource is where we read a remote's url from:
+	// PRRemoteURLSourceConfig reads remote.<name>.url from the git config and
+	// PRRemoteURLSourceGit asks 'git remote get-url --push', which takes pushurl
+	// overrides into account, falling back to the git config
+	RemoteURLSource string `yaml:"remoteURLSource"`
+
+	// CommandTimeout is how many milliseconds we give the git services' CLIs and
+	// PostCreateCommand to finish before giving up on them, so that a hung
+	// command doesn't leave us waiting forever. Zero means no limit
+	CommandTimeout int `yaml:"commandTimeout"`
+
+	// BranchNameRewrite rewrites branch names before we put them in pull request
+	// URLs, for hosts which know branches by another name, e.g. without a
+	// 'users/me/' namespace
+	BranchNameRewrite BranchNameRewrite `yaml:"branchNameRewrite"`
+
+	// DefaultBase is the branch pull requests are based on when we aren't told
+	// one, in place of the branch's upstream or the repo's default branch. A
+	// repo can override it in its RepoConfigFilename
+	DefaultBase string `yaml:"defaultBase"`
+
+	// DefaultBaseByDomain maps a git domain, as in Services, to the branch pull
+	// requests on its service are based on, taking precedence over DefaultBase
+	DefaultBaseByDomain map[string]string `yaml:"defaultBaseByDomain"`
+
+	// PostCreateCommand, if set, is run after we've created a pull request, e.g.
+	// to announce it in chat, with the pull request's link and branch given by
+	// {{link}} and {{branch}}. It failing doesn't undo the pull request
+	PostCreateCommand string `yaml:"postCreateCommand"`
+
+	// BitbucketAPI has us create Bitbucket Cloud pull requests with its REST API
+	// rather than by opening the web form, once both of its fields are set
+	BitbucketAPI BitbucketAPIConfig `yaml:"bitbucketAPI"`
+}
+
+// BitbucketAPIConfig holds the credentials we create Bitbucket Cloud pull
+// requests with
+type BitbucketAPIConfig struct {
+	// Username is the Bitbucket username the app password belongs to
+	Username string `yaml:"username"`
+	// AppPassword is an app password with the 'pullrequest:write' permission
+	AppPassword string `yaml:"appPassword"`
+}
+
+// BranchNameRewrite replaces the matches of Pattern, a regular expression, with
+// Replacement, which can refer to the pattern's groups as in '$1'. An empty
+// pattern leaves branch names alone
+type BranchNameRewrite struct {
+	Pattern     string `yaml:"pattern"`
+	Replacement string `yaml:"replacement"`
+}
+
+// Rewrite returns the branch name with the rewrite applied, or as it is if the
+// pattern is empty or invalid
+func (r BranchNameRewrite) Rewrite(branchName string) string {
+	if r.Pattern == "" {
+		return branchName
+	}
+
+	pattern, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return branchName
+	}
+
+	return pattern.ReplaceAllString(branchName, r.Replacement)
+}
+
+const (
+	PROpenModeBrowser   = "browser"
+	PROpenModeClipboard = "clipboard"
+)
+
+const (
+	PRClipboardFormatPlain    = "plain"
+	PRClipboardFormatMarkdown = "markdown"
+)
+
+const (
+	PRRemoteURLSourceConfig = "config"
+	PRRemoteURLSourceGit    = "git"
+)
+
 // OSConfig contains config on the level of the os
 type OSConfig struct {
 	// OpenCommand is the command for opening a file
 	OpenCommand string `yaml:"openCommand,omitempty"`
 
-	// OpenCommand is the command for opening a link
-	OpenLinkCommand string `yaml:"openLinkCommand,omitempty"`
+	// OpenLinkCommand is the command for opening a link, with the link given by
+	// {{link}} or {{.Link}} (or appended if neither is present). If empty, $BROWSER
+	// is used, falling back to the platform's default
+	OpenLinkCommand PlatformCommand `yaml:"openLinkCommand,omitempty"`
+
+	// PrivateWindowFlags maps the name of a browser's executable (e.g. 'firefox')
+	// to the flag which opens a link in a private window, on top of the browsers
+	// we already know about
+	PrivateWindowFlags map[string]string `yaml:"privateWindowFlags,omitempty"`
+}
+
+// PlatformCommand is a command which can be configured either as a single
+// string, or as a map from an OS (as in runtime.GOOS) to the command for that
+// OS, so that one config can be shared between machines
+type PlatformCommand struct {
+	Command    string
+	ByPlatform map[string]string
+}
+
+// ForPlatform returns the command for the given OS, which is the single command
+// if that's how it was configured
+func (c PlatformCommand) ForPlatform(goos string) string {
+	if c.ByPlatform != nil {
+		return c.ByPlatform[goos]
+	}
+
+	return c.Command
+}
+
+// IsZero lets the yaml package omit an unset command
+func (c PlatformCommand) IsZero() bool {
+	return c.Command == "" && c.ByPlatform == nil
+}
+
+func (c *PlatformCommand) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var command string
+	if err := unmarshal(&command); err == nil {
+		*c = PlatformCommand{Command: command}
+		return nil
+	}
+
+	var byPlatform map[string]string
+	if err := unmarshal(&byPlatform); err != nil {
+		return err
+	}
+	*c = PlatformCommand{ByPlatform: byPlatform}
+
+	return nil
+}
+
+func (c PlatformCommand) MarshalYAML() (interface{}, error) {
+	if c.ByPlatform != nil {
+		return c.ByPlatform, nil
+	}
+
+	return c.Command, nil
 }
 
 type CustomCommand struct {
@@ -442,10 +628,21 @@ func GetDefaultConfig() *UserConfig {
 				BulkMenu: "b",
 			},
 		},
-		OS:                   GetPlatformDefaultConfig(),
-		DisableStartupPopups: false,
-		CustomCommands:       []CustomCommand(nil),
-		Services:             map[string]string(nil),
-		NotARepository:       "prompt",
+		OS:                      GetPlatformDefaultConfig(),
+		DisableStartupPopups:    false,
+		CustomCommands:          []CustomCommand(nil),
+		Services:                map[string]string(nil),
+		DefaultService:          "",
+		PullRequestURLTemplates: map[string]string(nil),
+		PR: PRConfig{
+			AppendFormParam: true,
+			OpenAttempts:    1,
+			OpenRetryDelay:  200,
+			OpenMode:        PROpenModeBrowser,
+			ClipboardFormat: PRClipboardFormatPlain,
+			RemoteURLSource: PRRemoteURLSourceConfig,
+			CommandTimeout:  10000,
+		},
+		NotARepository: "prompt",
 	}
 }
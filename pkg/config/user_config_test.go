@@ -0,0 +1,65 @@
+package config
+
+import (
+	"testing"
+
+	yaml "github.com/jesseduffield/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPlatformCommandUnmarshalYAML is a function.
+func TestPlatformCommandUnmarshalYAML(t *testing.T) {
+	type scenario struct {
+		testName string
+		yaml     string
+		test     func(OSConfig, error)
+	}
+
+	scenarios := []scenario{
+		{
+			"reads a single command",
+			"openLinkCommand: 'firefox {{link}}'",
+			func(osConfig OSConfig, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "firefox {{link}}", osConfig.OpenLinkCommand.ForPlatform("linux"))
+				assert.Equal(t, "firefox {{link}}", osConfig.OpenLinkCommand.ForPlatform("windows"))
+			},
+		},
+		{
+			"reads a command per platform",
+			"openLinkCommand:\n  linux: 'xdg-open {{link}}'\n  darwin: 'open {{link}}'",
+			func(osConfig OSConfig, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "xdg-open {{link}}", osConfig.OpenLinkCommand.ForPlatform("linux"))
+				assert.Equal(t, "open {{link}}", osConfig.OpenLinkCommand.ForPlatform("darwin"))
+				assert.Equal(t, "", osConfig.OpenLinkCommand.ForPlatform("windows"))
+			},
+		},
+		{
+			"rejects a list",
+			"openLinkCommand: ['firefox', '{{link}}']",
+			func(osConfig OSConfig, err error) {
+				assert.Error(t, err)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			osConfig := OSConfig{}
+			err := yaml.Unmarshal([]byte(s.yaml), &osConfig)
+			s.test(osConfig, err)
+		})
+	}
+}
+
+// TestPlatformCommandMarshalYAML is a function.
+func TestPlatformCommandMarshalYAML(t *testing.T) {
+	output, err := yaml.Marshal(OSConfig{OpenLinkCommand: PlatformCommand{Command: "firefox {{link}}"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "openLinkCommand: firefox {{link}}\n", string(output))
+
+	output, err = yaml.Marshal(OSConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, "{}\n", string(output))
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ServiceProviders are the providers which can be used in the services config.
+// Each of them must be handled by commands.NewService. Service types registered
+// with commands.RegisterServiceType are added to it
+var ServiceProviders = []string{"github", "bitbucket", "bitbucketServer", "gitlab", "gitea", "forgejo", "gogs", "sourcehut", "azuredevops", "codecommit", "phabricator"}
+
+// Validate returns an error describing the first invalid entry in the user config
+func (config *UserConfig) Validate() error {
+	if err := validateServices(config.Services); err != nil {
+		return err
+	}
+
+	if err := validateDefaultService(config.DefaultService); err != nil {
+		return err
+	}
+
+	if err := validatePROpenMode(config.PR.OpenMode); err != nil {
+		return err
+	}
+
+	if err := validatePRClipboardFormat(config.PR.ClipboardFormat); err != nil {
+		return err
+	}
+
+	if err := validatePRRemoteURLSource(config.PR.RemoteURLSource); err != nil {
+		return err
+	}
+
+	return validatePRBranchNameRewrite(config.PR.BranchNameRewrite)
+}
+
+func validatePROpenMode(openMode string) error {
+	switch openMode {
+	case "", PROpenModeBrowser, PROpenModeClipboard:
+		return nil
+	}
+
+	return fmt.Errorf(
+		"Unknown pr.openMode '%s'. Expected '%s' or '%s'",
+		openMode, PROpenModeBrowser, PROpenModeClipboard,
+	)
+}
+
+func validatePRClipboardFormat(clipboardFormat string) error {
+	switch clipboardFormat {
+	case "", PRClipboardFormatPlain, PRClipboardFormatMarkdown:
+		return nil
+	}
+
+	return fmt.Errorf(
+		"Unknown pr.clipboardFormat '%s'. Expected '%s' or '%s'",
+		clipboardFormat, PRClipboardFormatPlain, PRClipboardFormatMarkdown,
+	)
+}
+
+func validatePRRemoteURLSource(remoteURLSource string) error {
+	switch remoteURLSource {
+	case "", PRRemoteURLSourceConfig, PRRemoteURLSourceGit:
+		return nil
+	}
+
+	return fmt.Errorf(
+		"Unknown pr.remoteURLSource '%s'. Expected '%s' or '%s'",
+		remoteURLSource, PRRemoteURLSourceConfig, PRRemoteURLSourceGit,
+	)
+}
+
+func validatePRBranchNameRewrite(rewrite BranchNameRewrite) error {
+	if _, err := regexp.Compile(rewrite.Pattern); err != nil {
+		return fmt.Errorf("Invalid pr.branchNameRewrite pattern '%s': %v", rewrite.Pattern, err)
+	}
+
+	return nil
+}
+
+func validateDefaultService(defaultService string) error {
+	if defaultService != "" && !isServiceProvider(defaultService) {
+		return fmt.Errorf(
+			"Unknown provider '%s' in defaultService. Supported providers are: %s",
+			defaultService, strings.Join(ServiceProviders, ", "),
+		)
+	}
+
+	return nil
+}
+
+func validateServices(services map[string]string) error {
+	// sorting so that we always complain about the same entry first
+	gitDomains := make([]string, 0, len(services))
+	for gitDomain := range services {
+		gitDomains = append(gitDomains, gitDomain)
+	}
+	sort.Strings(gitDomains)
+
+	for _, gitDomain := range gitDomains {
+		providerAndWebDomain := services[gitDomain]
+		provider, _, ok := SplitServiceEntry(providerAndWebDomain)
+		if !ok {
+			return fmt.Errorf(
+				"Invalid services entry '%s: %s'. Expected a value of the form '<provider>:<webDomain>', e.g. 'gitlab:gitlab.mycompany.com'",
+				gitDomain, providerAndWebDomain,
+			)
+		}
+
+		if !isServiceProvider(provider) {
+			return fmt.Errorf(
+				"Unknown provider '%s' in services entry '%s: %s'. Supported providers are: %s",
+				provider, gitDomain, providerAndWebDomain, strings.Join(ServiceProviders, ", "),
+			)
+		}
+	}
+
+	return nil
+}
+
+// SplitServiceEntry splits the value of a services entry like
+// 'gitlab:gitlab.mycompany.com' into its provider and web domain, returning
+// false if it isn't of that form. Providers have no colons, so we split on the
+// first one, and the web domain may be an IPv6 address like 'fd00::5', with or
+// without the brackets it's given in urls
+func SplitServiceEntry(providerAndWebDomain string) (string, string, bool) {
+	colonIndex := strings.Index(providerAndWebDomain, ":")
+	if colonIndex == -1 {
+		return "", "", false
+	}
+
+	provider := providerAndWebDomain[:colonIndex]
+	webDomain := providerAndWebDomain[colonIndex+1:]
+	if provider == "" || webDomain == "" {
+		return "", "", false
+	}
+
+	if strings.Contains(webDomain, ":") && !IsBracketedIPv6(webDomain) && !isIPv6(webDomain) {
+		return "", "", false
+	}
+
+	return provider, webDomain, true
+}
+
+// IsBracketedIPv6 returns true for an IPv6 address in the brackets it's given in
+// urls, e.g. '[::1]'
+func IsBracketedIPv6(host string) bool {
+	if !strings.HasPrefix(host, "[") || !strings.HasSuffix(host, "]") {
+		return false
+	}
+
+	return isIPv6(host[1 : len(host)-1])
+}
+
+func isIPv6(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() == nil
+}
+
+func isServiceProvider(name string) bool {
+	for _, provider := range ServiceProviders {
+		if provider == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,325 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateServices is a function.
+func TestValidateServices(t *testing.T) {
+	type scenario struct {
+		testName string
+		services map[string]string
+		test     func(error)
+	}
+
+	scenarios := []scenario{
+		{
+			"accepts valid entries",
+			map[string]string{
+				"git.work.com":      "gitlab:code.work.com",
+				"github.mycorp.net": "github:github.mycorp.net",
+				"git.forge.org":     "forgejo:forge.org",
+			},
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"accepts entries for IP addresses",
+			map[string]string{
+				"10.0.0.5":  "gitlab:10.0.0.5",
+				"[fd00::5]": "gitea:[fd00::5]",
+			},
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"accepts a bare IPv6 web domain",
+			map[string]string{
+				"[fd00::5]": "gitea:fd00::5",
+			},
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"rejects a web domain with colons which isn't an IPv6 address",
+			map[string]string{
+				"[fd00::5]": "gitea:fd00::5::6",
+			},
+			func(err error) {
+				assert.EqualError(t, err, "Invalid services entry '[fd00::5]: gitea:fd00::5::6'. Expected a value of the form '<provider>:<webDomain>', e.g. 'gitlab:gitlab.mycompany.com'")
+			},
+		},
+		{
+			"accepts no entries",
+			nil,
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"rejects an entry without a provider",
+			map[string]string{
+				"noservice.work.com": "noservice.work.com",
+			},
+			func(err error) {
+				assert.EqualError(t, err, "Invalid services entry 'noservice.work.com: noservice.work.com'. Expected a value of the form '<provider>:<webDomain>', e.g. 'gitlab:gitlab.mycompany.com'")
+			},
+		},
+		{
+			"rejects an entry with an empty provider",
+			map[string]string{
+				"git.work.com": ":code.work.com",
+			},
+			func(err error) {
+				assert.EqualError(t, err, "Invalid services entry 'git.work.com: :code.work.com'. Expected a value of the form '<provider>:<webDomain>', e.g. 'gitlab:gitlab.mycompany.com'")
+			},
+		},
+		{
+			"rejects an entry with an empty web domain",
+			map[string]string{
+				"git.work.com": "gitlab:",
+			},
+			func(err error) {
+				assert.EqualError(t, err, "Invalid services entry 'git.work.com: gitlab:'. Expected a value of the form '<provider>:<webDomain>', e.g. 'gitlab:gitlab.mycompany.com'")
+			},
+		},
+		{
+			"rejects an entry with too many separators",
+			map[string]string{
+				"git.work.com": "gitlab:code.work.com:8080",
+			},
+			func(err error) {
+				assert.EqualError(t, err, "Invalid services entry 'git.work.com: gitlab:code.work.com:8080'. Expected a value of the form '<provider>:<webDomain>', e.g. 'gitlab:gitlab.mycompany.com'")
+			},
+		},
+		{
+			"rejects an entry with an unknown provider",
+			map[string]string{
+				"invalid.work.com": "noservice:invalid.work.com",
+			},
+			func(err error) {
+				assert.EqualError(t, err, "Unknown provider 'noservice' in services entry 'invalid.work.com: noservice:invalid.work.com'. Supported providers are: github, bitbucket, bitbucketServer, gitlab, gitea, forgejo, gogs, sourcehut, azuredevops, codecommit, phabricator")
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			s.test(validateServices(s.services))
+		})
+	}
+}
+
+// TestValidateDefaultService is a function.
+func TestValidateDefaultService(t *testing.T) {
+	type scenario struct {
+		testName       string
+		defaultService string
+		test           func(error)
+	}
+
+	scenarios := []scenario{
+		{
+			"accepts a known provider",
+			"gitlab",
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"accepts no default service",
+			"",
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"rejects an unknown provider",
+			"noservice",
+			func(err error) {
+				assert.EqualError(t, err, "Unknown provider 'noservice' in defaultService. Supported providers are: github, bitbucket, bitbucketServer, gitlab, gitea, forgejo, gogs, sourcehut, azuredevops, codecommit, phabricator")
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			s.test(validateDefaultService(s.defaultService))
+		})
+	}
+}
+
+// TestValidatePROpenMode is a function.
+func TestValidatePROpenMode(t *testing.T) {
+	type scenario struct {
+		testName string
+		openMode string
+		test     func(error)
+	}
+
+	scenarios := []scenario{
+		{
+			"accepts browser",
+			"browser",
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"accepts clipboard",
+			"clipboard",
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"rejects an unknown mode",
+			"pigeon",
+			func(err error) {
+				assert.EqualError(t, err, "Unknown pr.openMode 'pigeon'. Expected 'browser' or 'clipboard'")
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			s.test(validatePROpenMode(s.openMode))
+		})
+	}
+}
+
+// TestValidatePRClipboardFormat is a function.
+func TestValidatePRClipboardFormat(t *testing.T) {
+	type scenario struct {
+		testName        string
+		clipboardFormat string
+		test            func(error)
+	}
+
+	scenarios := []scenario{
+		{
+			"accepts plain",
+			"plain",
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"accepts markdown",
+			"markdown",
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"accepts no clipboard format",
+			"",
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"rejects an unknown clipboard format",
+			"html",
+			func(err error) {
+				assert.EqualError(t, err, "Unknown pr.clipboardFormat 'html'. Expected 'plain' or 'markdown'")
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			s.test(validatePRClipboardFormat(s.clipboardFormat))
+		})
+	}
+}
+
+// TestValidatePRRemoteURLSource is a function.
+func TestValidatePRRemoteURLSource(t *testing.T) {
+	type scenario struct {
+		testName        string
+		remoteURLSource string
+		test            func(error)
+	}
+
+	scenarios := []scenario{
+		{
+			"accepts config",
+			"config",
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"accepts git",
+			"git",
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"accepts no remote url source",
+			"",
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"rejects an unknown remote url source",
+			"remote",
+			func(err error) {
+				assert.EqualError(t, err, "Unknown pr.remoteURLSource 'remote'. Expected 'config' or 'git'")
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			s.test(validatePRRemoteURLSource(s.remoteURLSource))
+		})
+	}
+}
+
+// TestValidatePRBranchNameRewrite is a function.
+func TestValidatePRBranchNameRewrite(t *testing.T) {
+	type scenario struct {
+		testName string
+		rewrite  BranchNameRewrite
+		test     func(error)
+	}
+
+	scenarios := []scenario{
+		{
+			"accepts a pattern stripping a prefix",
+			BranchNameRewrite{Pattern: "^users/[^/]+/"},
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"accepts no pattern",
+			BranchNameRewrite{},
+			func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			"rejects an invalid pattern",
+			BranchNameRewrite{Pattern: "^users/(["},
+			func(err error) {
+				assert.EqualError(t, err, "Invalid pr.branchNameRewrite pattern '^users/([': error parsing regexp: missing closing ]: `[`")
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			s.test(validatePRBranchNameRewrite(s.rewrite))
+		})
+	}
+}
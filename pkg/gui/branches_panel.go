@@ -7,6 +7,7 @@ import (
 	"github.com/jesseduffield/gocui"
 	"github.com/jesseduffield/lazygit/pkg/commands"
 	"github.com/jesseduffield/lazygit/pkg/commands/models"
+	"github.com/jesseduffield/lazygit/pkg/config"
 	"github.com/jesseduffield/lazygit/pkg/gui/presentation"
 	"github.com/jesseduffield/lazygit/pkg/gui/types"
 	"github.com/jesseduffield/lazygit/pkg/utils"
@@ -94,11 +95,82 @@ func (gui *Gui) handleCreatePullRequestPress(g *gocui.Gui, v *gocui.View) error
 	pullRequest := commands.NewPullRequest(gui.GitCommand)
 
 	branch := gui.getSelectedBranch()
-	if err := pullRequest.Create(branch); err != nil {
-		return gui.surfaceError(err)
+	var createPullRequest func(opts commands.PullRequestOptions) error
+	createPullRequest = func(opts commands.PullRequestOptions) error {
+		pullRequest.CreateWithOptionsAsync(branch, opts, func(url string, err error) {
+			gui.g.Update(func(*gocui.Gui) error {
+				if defaultBranchErr, ok := err.(*commands.ErrDefaultBranchPullRequest); ok {
+					return gui.ask(askOpts{
+						title:  gui.Tr.DefaultBranchPullRequestTitle,
+						prompt: fmt.Sprintf(gui.Tr.DefaultBranchPullRequestPrompt, defaultBranchErr.Branch),
+						handleConfirm: func() error {
+							opts.AllowDefaultBranch = true
+							return createPullRequest(opts)
+						},
+					})
+				}
+				postCreateErr, postCreateFailed := err.(*commands.ErrPostCreateCommand)
+				if err != nil && !postCreateFailed {
+					return gui.surfacePullRequestError(err)
+				}
+				if url == "" {
+					return nil
+				}
+				if gui.Config.GetUserConfig().PR.OpenMode == config.PROpenModeClipboard {
+					gui.raiseToast(gui.Tr.PullRequestURLCopiedToClipboard)
+				} else {
+					gui.raiseToast(fmt.Sprintf(gui.Tr.OpenedPullRequest, url))
+				}
+				if postCreateFailed {
+					return gui.createErrorPanel(postCreateErr.Error())
+				}
+				return nil
+			})
+		})
+
+		return nil
 	}
 
-	return nil
+	// a pull request without any commits is almost certainly a mistake
+	createNonEmptyPullRequest := func() error {
+		// we count the commits ahead of the branch the pull request merges into,
+		// which needn't be the repo's default branch
+		base, err := pullRequest.TargetBranch(branch, commands.PullRequestOptions{})
+		if err == nil && base != "" && base != branch.Name {
+			if ahead, _, err := gui.GitCommand.CommitsAhead(base, branch.Name); err == nil && ahead == 0 {
+				return gui.ask(askOpts{
+					title:  gui.Tr.EmptyPullRequestTitle,
+					prompt: fmt.Sprintf(gui.Tr.EmptyPullRequestPrompt, branch.Name, base),
+					handleConfirm: func() error {
+						return createPullRequest(commands.PullRequestOptions{})
+					},
+				})
+			}
+		}
+
+		return createPullRequest(commands.PullRequestOptions{})
+	}
+
+	// uncommitted changes won't make it into the pull request, which is easy to
+	// miss when opening one for the checked out branch
+	if branch.Head {
+		if clean, err := gui.GitCommand.IsWorkingTreeClean(); err == nil && !clean {
+			return gui.ask(askOpts{
+				title:  gui.Tr.UncommittedChangesPullRequestTitle,
+				prompt: fmt.Sprintf(gui.Tr.UncommittedChangesPullRequestPrompt, branch.Name),
+				handleConfirm: func() error {
+					// the confirmation closes after this returns, so we wait for
+					// that before possibly asking about an empty pull request
+					gui.g.Update(func(*gocui.Gui) error {
+						return createNonEmptyPullRequest()
+					})
+					return nil
+				},
+			})
+		}
+	}
+
+	return createNonEmptyPullRequest()
 }
 
 func (gui *Gui) handleCopyPullRequestURLPress(g *gocui.Gui, v *gocui.View) error {
@@ -106,7 +178,7 @@ func (gui *Gui) handleCopyPullRequestURLPress(g *gocui.Gui, v *gocui.View) error
 
 	branch := gui.getSelectedBranch()
 	if err := pullRequest.CopyURL(branch); err != nil {
-		return gui.surfaceError(err)
+		return gui.surfacePullRequestError(err)
 	}
 
 	gui.raiseToast(gui.Tr.PullRequestURLCopiedToClipboard)
@@ -114,6 +186,16 @@ func (gui *Gui) handleCopyPullRequestURLPress(g *gocui.Gui, v *gocui.View) error
 	return nil
 }
 
+// surfacePullRequestError points the user to the services config if we don't
+// know which git service their remote belongs to
+func (gui *Gui) surfacePullRequestError(err error) error {
+	if unsupportedErr, ok := err.(*commands.ErrUnsupportedGitService); ok {
+		return gui.createErrorPanel(fmt.Sprintf(gui.Tr.UnsupportedGitServiceHint, unsupportedErr.Host))
+	}
+
+	return gui.surfaceError(err)
+}
+
 func (gui *Gui) handleGitFetch(g *gocui.Gui, v *gocui.View) error {
 	if err := gui.createLoaderPanel(gui.Tr.FetchWait); err != nil {
 		return err
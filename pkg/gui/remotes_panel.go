@@ -41,6 +41,9 @@ func (gui *Gui) handleRemoteSelect() error {
 func (gui *Gui) refreshRemotes() error {
 	prevSelectedRemote := gui.getSelectedRemote()
 
+	// the remotes may have been changed outside of lazygit
+	gui.GitCommand.ClearRemoteRepoInfoCache()
+
 	remotes, err := gui.GitCommand.GetRemotes()
 	if err != nil {
 		return gui.surfaceError(err)
@@ -166,9 +166,39 @@ type TranslationSet struct {
 	SwitchRepo                          string
 	LcAllBranchesLogGraph               string
 	UnsupportedGitService               string
+	UnsupportedGitServiceHint           string
 	LcCreatePullRequest                 string
 	LcCopyPullRequestURL                string
 	NoBranchOnRemote                    string
+	ListPullRequestsUnsupported         string
+	PullRequestCLINotFound              string
+	CreatePullRequestAPIFailed          string
+	TargetProjectNotFound               string
+	PostCreateCommandFailed             string
+	DraftPullRequestsUnsupported        string
+	RemoteURLNotFound                   string
+	NoBranchContainsRef                 string
+	MultipleBranchesContainRef          string
+	DetachedHeadPullRequest             string
+	LocalRemoteUnsupported              string
+	LaunchpadUnsupported                string
+	WikiRemoteUnsupported               string
+	GistRemoteUnsupported               string
+	ArcNotFound                         string
+	EmptyPullRequestTitle               string
+	EmptyPullRequestPrompt              string
+	DefaultBranchPullRequest            string
+	DefaultBranchPullRequestTitle       string
+	DefaultBranchPullRequestPrompt      string
+	UncommittedChangesPullRequestTitle  string
+	UncommittedChangesPullRequestPrompt string
+	CompareUnsupported                  string
+	BlameUnsupported                    string
+	NewIssueUnsupported                 string
+	PipelinesUnsupported                string
+	TagURLUnsupported                   string
+	InvalidRemoteURL                    string
+	RemoteURLWithoutHost                string
 	LcFetch                             string
 	NoAutomaticGitFetchTitle            string
 	NoAutomaticGitFetchBody             string
@@ -432,6 +462,7 @@ type TranslationSet struct {
 	NavigationTitle                     string
 	PushingTagStatus                    string
 	PullRequestURLCopiedToClipboard     string
+	OpenedPullRequest                   string
 	CommitMessageCopiedToClipboard      string
 	LcCopiedToClipboard                 string
 }
@@ -695,9 +726,39 @@ func englishTranslationSet() TranslationSet {
 		SwitchRepo:                          `switch to a recent repo`,
 		LcAllBranchesLogGraph:               `show all branch logs`,
 		UnsupportedGitService:               `Unsupported git service`,
+		UnsupportedGitServiceHint:           `Unsupported git service for host '%s'. Add this host to the services section of your config to tell lazygit which git service it belongs to`,
 		LcCreatePullRequest:                 `create pull request`,
 		LcCopyPullRequestURL:                `copy pull request URL to clipboard`,
 		NoBranchOnRemote:                    `This branch doesn't exist on remote. You need to push it to remote first.`,
+		ListPullRequestsUnsupported:         `Listing pull requests isn't supported for this git service`,
+		PullRequestCLINotFound:              `Listing pull requests requires the '%s' CLI to be installed`,
+		CreatePullRequestAPIFailed:          `Failed to create the pull request: %s`,
+		TargetProjectNotFound:               `Couldn't find the ID of the project '%s' to merge into: %s`,
+		PostCreateCommandFailed:             `The pull request was created, but the post-create command failed: %s`,
+		DraftPullRequestsUnsupported:        `Draft pull requests aren't supported for this git service`,
+		RemoteURLNotFound:                   `Could not find a url for remote '%s'`,
+		NoBranchContainsRef:                 `Could not find a branch containing '%s'`,
+		MultipleBranchesContainRef:          `'%s' is on more than one branch (%s). Pick one of those branches instead`,
+		DetachedHeadPullRequest:             `Can't create a pull request while HEAD is detached. Check out a branch first`,
+		LocalRemoteUnsupported:              `'%s' is a local remote, so there's no git service to open it on`,
+		LaunchpadUnsupported:                `Launchpad isn't supported as a git service. You can find the repository and propose merges at https://%s`,
+		WikiRemoteUnsupported:               `Remote '%s' points to a wiki rather than a repository, so there are no pull requests or links to open for it`,
+		GistRemoteUnsupported:               `Remote '%s' points to a gist rather than a repository, so there are no pull requests or links to open for it`,
+		ArcNotFound:                         `Creating a Phabricator revision requires the 'arc' CLI to be installed`,
+		EmptyPullRequestTitle:               `Empty pull request`,
+		EmptyPullRequestPrompt:              `'%s' has no commits ahead of '%s'. Create a pull request anyway?`,
+		DefaultBranchPullRequest:            `'%s' is the default branch, so the pull request would merge it into itself`,
+		DefaultBranchPullRequestTitle:       `Pull request from the default branch`,
+		DefaultBranchPullRequestPrompt:      `'%s' is the default branch, so the pull request would merge it into itself. Create it anyway?`,
+		UncommittedChangesPullRequestTitle:  `Uncommitted changes`,
+		UncommittedChangesPullRequestPrompt: `You have changes which aren't committed, so they won't be part of the pull request for '%s'. Create it anyway?`,
+		CompareUnsupported:                  `Comparing branches isn't supported for this git service`,
+		BlameUnsupported:                    `Viewing blame isn't supported for this git service`,
+		NewIssueUnsupported:                 `Opening issues isn't supported for this git service`,
+		PipelinesUnsupported:                `Viewing pipelines isn't supported for this git service`,
+		TagURLUnsupported:                   `Viewing tags isn't supported for this git service`,
+		InvalidRemoteURL:                    `Could not make sense of the url of remote '%s': %s`,
+		RemoteURLWithoutHost:                `The url of remote '%s' has no host: %s. You can fix it with 'git remote set-url'`,
 		LcFetch:                             `fetch`,
 		NoAutomaticGitFetchTitle:            `No automatic git fetch`,
 		NoAutomaticGitFetchBody:             `Lazygit can't use "git fetch" in a private repo; use 'f' in the files panel to run "git fetch" manually`,
@@ -962,6 +1023,7 @@ func englishTranslationSet() TranslationSet {
 		NavigationTitle:                     "List Panel Navigation",
 		PushingTagStatus:                    "pushing tag",
 		PullRequestURLCopiedToClipboard:     "Pull request URL copied to clipboard",
+		OpenedPullRequest:                   "Opened %s",
 		CommitMessageCopiedToClipboard:      "Commit message copied to clipboard",
 		LcCopiedToClipboard:                 "copied to clipboard",
 	}